@@ -0,0 +1,10 @@
+package xpo
+
+//Accounting holds the caller's own codes for allocating freight spend
+//These are never sent to XPO.  They are kept on stored pickups and queued pickups and are included in exports
+//so spend can be split up without looking each pickup up somewhere else.
+type Accounting struct {
+	GLCode     string `json:"glCode,omitempty"` //general ledger account
+	CostCenter string `json:"costCenter,omitempty"`
+	Department string `json:"department,omitempty"`
+}
@@ -0,0 +1,77 @@
+package xpo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//AlertKind is why an alert was raised
+type AlertKind string
+
+//alert kinds
+const (
+	AlertUnconfirmed AlertKind = "pickup.unconfirmed" //a queued pickup still hasn't been confirmed by XPO
+	AlertStuck       AlertKind = "shipment.stuck"     //a tracked shipment has had no tracking movement for a while
+)
+
+//Alert is raised when something needs a person to look at it
+//Only the fields that make sense for the kind are set.
+type Alert struct {
+	Kind      AlertKind         `json:"kind"`
+	Time      time.Time         `json:"time"`
+	Tenant    string            `json:"tenant,omitempty"`
+	ID        string            `json:"id,omitempty"`        //queued pickup id
+	PRO       string            `json:"pro,omitempty"`       //stuck shipment
+	Status    string            `json:"status,omitempty"`    //stuck shipment's status
+	Since     time.Time         `json:"since"`               //when the pickup was first submitted, or the shipment last moved
+	Attempts  int               `json:"attempts,omitempty"`  //times sending has been tried
+	LastError string            `json:"lastError,omitempty"` //why the last try failed
+	Metadata  map[string]string `json:"metadata,omitempty"`  //copied from the request
+}
+
+//AlertFunc is called with each alert
+//Return an error if the alert could not be delivered so it is raised again next time.
+type AlertFunc func(ctx context.Context, a Alert) error
+
+//WebhookAlert returns an AlertFunc that posts each alert as json to url
+//httpClient defaults to http.DefaultClient, give one with a timeout so a slow webhook doesn't hold up the outbox.
+func WebhookAlert(url string, httpClient *http.Client) AlertFunc {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return func(ctx context.Context, a Alert) (err error) {
+		b, err := json.Marshal(a)
+		if err != nil {
+			err = errors.Wrap(err, "xpo.WebhookAlert - could not marshal alert")
+			return
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+		if err != nil {
+			err = errors.Wrap(err, "xpo.WebhookAlert - could not build request")
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		res, err := httpClient.Do(req)
+		if err != nil {
+			err = errors.Wrap(err, "xpo.WebhookAlert - could not post alert")
+			return
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			err = errors.New("xpo.WebhookAlert - webhook responded with status " + strconv.Itoa(res.StatusCode))
+			return
+		}
+
+		return
+	}
+}
@@ -0,0 +1,74 @@
+package xpo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+//audit results
+const (
+	AuditResultSuccess = "success"
+	AuditResultFailure = "failure"
+)
+
+//AuditRecord is the info saved about each request made to XPO
+//The payload itself is not kept, just a hash of it with any secrets removed, so records can be matched up
+//against stored requests without the audit log holding credentials.
+type AuditRecord struct {
+	Timestamp       time.Time //when the request was started
+	Tenant          string
+	Mode            Mode
+	Endpoint        string          //EndpointToken, EndpointPickup, etc.
+	PayloadHash     string          //hex encoded sha256 of the sanitized request body
+	StatusCode      int             //http status code, 0 if no response was received
+	Result          string          //AuditResultSuccess or AuditResultFailure
+	Error           string          //error message on failure
+	ConfirmationNbr ConfirmationNbr //pickup confirmation number on a successful pickup request
+}
+
+//AuditSink receives a record for every request a client makes
+//Audit is called synchronously after each request so implementations should be quick and must handle
+//their own storage errors.  Set this on Config.AuditSink.
+type AuditSink interface {
+	Audit(AuditRecord)
+}
+
+//AuditFunc allows a plain func to be used as an AuditSink
+type AuditFunc func(AuditRecord)
+
+//Audit calls f(r)
+func (f AuditFunc) Audit(r AuditRecord) {
+	f(r)
+}
+
+//payloadHash returns the hex encoded sha256 of a sanitized request body
+func payloadHash(sanitized []byte) string {
+	sum := sha256.Sum256(sanitized)
+	return hex.EncodeToString(sum[:])
+}
+
+//audit builds an audit record and sends it to the client's sink, if one was given
+func (c *Client) audit(start time.Time, endpoint string, hash string, statusCode int, confirmationNbr ConfirmationNbr, err error) {
+	if c.auditSink == nil {
+		return
+	}
+
+	r := AuditRecord{
+		Timestamp:       start,
+		Tenant:          c.tenant,
+		Mode:            c.mode,
+		Endpoint:        endpoint,
+		PayloadHash:     hash,
+		StatusCode:      statusCode,
+		Result:          AuditResultSuccess,
+		ConfirmationNbr: confirmationNbr,
+	}
+	if err != nil {
+		r.Result = AuditResultFailure
+		r.Error = err.Error()
+	}
+
+	c.auditSink.Audit(r)
+	return
+}
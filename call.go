@@ -0,0 +1,261 @@
+package xpo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/coreymgilmore/xpologistics/transport"
+	"github.com/pkg/errors"
+)
+
+//Endpoint describes an XPO endpoint for Call
+type Endpoint struct {
+	Name   string //used in latency stats and audit records
+	Method string //defaults to POST
+	URL    string //full url, including any query parameters
+
+	//Booking is set for endpoints that book something with XPO, read only clients refuse these
+	Booking bool
+
+	//Stream encodes the request body straight to the connection instead of building it in memory first
+	//Use this for large batch payloads.  The body is sent chunked.
+	Stream bool
+}
+
+//responseChecker is implemented by responses that can be decoded fine but still mean the request failed,
+//for example a pickup response with no confirmation number.
+type responseChecker interface {
+	check() error
+}
+
+//confirmer is implemented by responses that have a confirmation number to save in the audit record
+type confirmer interface {
+	confirmationNbr() ConfirmationNbr
+}
+
+//Call sends req to an XPO endpoint as JSON and decodes the response into TResp
+//This handles everything that is the same for every endpoint: the production guard, getting a bearer
+//token, auditing, XML faults, and adding the mode to errors.  Endpoint methods should be a thin wrapper
+//around this.
+func Call[TReq, TResp any](ctx context.Context, c *Client, e Endpoint, req TReq) (resp TResp, err error) {
+	defer func() {
+		err = c.wrapMode(err)
+	}()
+
+	//audit the request once we know how it turned out
+	var hash string
+	var statusCode int
+	start := c.now()
+	defer func() {
+		var confirmationNbr ConfirmationNbr
+		if v, ok := any(&resp).(confirmer); ok {
+			confirmationNbr = v.confirmationNbr()
+		}
+		c.audit(start, e.Name, hash, statusCode, confirmationNbr, err)
+	}()
+
+	//make sure we are allowed to use this mode and endpoint before doing anything with XPO
+	err = c.checkAllowed(e)
+	if err != nil {
+		return
+	}
+
+	e.Stream = e.Stream || c.stream
+	body, hash, err := encodeBody(e, req)
+	if err != nil {
+		err = errors.Wrapf(err, "xpo.Call %s - could not marshal json", e.Name)
+		return
+	}
+
+	r, err := c.request(ctx, e, body)
+	if err != nil {
+		err = errors.Wrapf(err, "xpo.Call %s - could not get token", e.Name)
+		return
+	}
+
+	res, err := c.transport.Do(ctx, r)
+	if err == nil && r.Gzip && res.StatusCode == http.StatusUnsupportedMediaType {
+		//XPO didn't take the compressed body and so didn't process it, send it again uncompressed from now on
+		c.logf("xpo.Call %s - compressed request rejected, sending uncompressed", e.Name)
+		c.gzip.Store(false)
+		r.Gzip = false
+		res, err = c.transport.Do(ctx, r)
+	}
+	if err == nil && tokenRejected(res) {
+		//the cached token was revoked or expired early, XPO didn't process the request so get a new token and
+		//send it again, once
+		c.logf("xpo.Call %s - bearer token rejected, getting a new one", e.Name)
+		r.BearerToken, err = c.renewToken(ctx)
+		if err != nil {
+			err = errors.Wrapf(err, "xpo.Call %s - could not get token", e.Name)
+			return
+		}
+		res, err = c.transport.Do(ctx, r)
+	}
+	if err != nil {
+		err = errors.Wrapf(err, "xpo.Call %s - could not make request", e.Name)
+		return
+	}
+	statusCode = res.StatusCode
+
+	if m, ok := maintenance(res, c.now()); ok {
+		err = m
+		return
+	}
+
+	//data might not be json, might be xml error
+	err = transport.Decode(res.Body, &resp)
+	if err != nil {
+		if fault, ok := errors.Cause(err).(*transport.Fault); ok {
+			//return error so we know we need to fix something
+			c.logf("%+v", *fault)
+			err = &APIError{
+				StatusCode:  res.StatusCode,
+				Code:        strings.TrimSpace(fault.Code),
+				Type:        fault.Type,
+				Message:     c.faultMessage(*fault),
+				Description: fault.Description,
+			}
+			return
+		}
+
+		err = errors.Wrapf(err, "xpo.Call %s - could not unmarshal response", e.Name)
+		return
+	}
+
+	//some responses decode fine but are still a failure, log the response data so we can see why
+	if v, ok := any(&resp).(responseChecker); ok {
+		err = v.check()
+		if err != nil {
+			c.logf("%v", err)
+			c.logf("%s", res.Body)
+			return
+		}
+	}
+
+	return
+}
+
+//BuildRequest prepares the exact http request Call would send, without sending it
+//Use this to inspect headers, body, and auth for security review or debugging.  A bearer token is retrieved
+//from XPO to fill in the Authorization header, which doesn't book anything.
+func BuildRequest[TReq any](ctx context.Context, c *Client, e Endpoint, req TReq) (httpReq *http.Request, err error) {
+	defer func() {
+		err = c.wrapMode(err)
+	}()
+
+	err = c.checkAllowed(e)
+	if err != nil {
+		return
+	}
+
+	body, _, err := encodeBody(e, req)
+	if err != nil {
+		err = errors.Wrapf(err, "xpo.BuildRequest %s - could not marshal json", e.Name)
+		return
+	}
+
+	r, err := c.request(ctx, e, body)
+	if err != nil {
+		err = errors.Wrapf(err, "xpo.BuildRequest %s - could not get token", e.Name)
+		return
+	}
+
+	httpReq, err = transport.NewRequest(ctx, r)
+	return
+}
+
+//request builds the transport request for an endpoint, getting a bearer token for it
+func (c *Client) request(ctx context.Context, e Endpoint, body requestBody) (r transport.Request, err error) {
+	bearerToken, err := c.getRequestToken(ctx)
+	if err != nil {
+		return
+	}
+
+	r = transport.Request{
+		Endpoint:    e.Name,
+		Method:      e.Method,
+		URL:         e.URL,
+		Body:        body.raw,
+		BodyWriter:  body.write,
+		Gzip:        c.gzip.Load(),
+		BearerToken: bearerToken,
+	}
+	return
+}
+
+//tokenRejected checks if XPO refused a request's bearer token
+func tokenRejected(res transport.Response) bool {
+	if res.StatusCode == http.StatusUnauthorized {
+		return true
+	}
+	if res.StatusCode < http.StatusBadRequest {
+		return false
+	}
+
+	var fault *transport.Fault
+	err := transport.Decode(res.Body, &struct{}{})
+	return errors.As(err, &fault) && strings.TrimSpace(fault.Code) == ErrCodeInvalidCredentials
+}
+
+//requestBody is an encoded request body, either marshaled up front or streamed as it is sent
+type requestBody struct {
+	raw   []byte
+	write transport.BodyWriter
+}
+
+//encodeBody encodes req as JSON for e and returns the payload hash for the audit record
+//When streaming, the body is encoded once here into the hash and again as it is sent so the whole body is
+//never held in memory.
+func encodeBody[TReq any](e Endpoint, req TReq) (body requestBody, hash string, err error) {
+	//GET requests have no body, everything is in the url
+	if e.Method == http.MethodGet {
+		hash = payloadHash(nil)
+		return
+	}
+
+	if !e.Stream {
+		body.raw, err = json.Marshal(req)
+		if err != nil {
+			return
+		}
+
+		hash = payloadHash(body.raw)
+		return
+	}
+
+	h := sha256.New()
+	err = json.NewEncoder(h).Encode(req)
+	if err != nil {
+		return
+	}
+
+	body.write = func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(req)
+	}
+	hash = hex.EncodeToString(h.Sum(nil))
+	return
+}
+
+//checkAllowed is the guard that stops requests this client isn't allowed to make
+func (c *Client) checkAllowed(e Endpoint) error {
+	if e.Booking && c.readOnly {
+		return errors.Wrapf(ErrReadOnly, "xpo.Call %s", e.Name)
+	}
+
+	return c.checkMode()
+}
+
+//checkMode is the guard that stops production requests unless they were explicitly allowed
+func (c *Client) checkMode() error {
+	if c.mode == ModeProduction && !c.allowProduction {
+		return ErrProductionNotAllowed
+	}
+
+	return nil
+}
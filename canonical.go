@@ -0,0 +1,43 @@
+package xpo
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+//CanonicalJSON returns a stable, readable JSON encoding of v for golden file tests
+//Keys are sorted, indented with two spaces, numbers are kept exactly as encoded, and the output ends with a
+//newline.  Encoding the same value always gives the same bytes so fixtures don't churn when struct fields
+//are reordered.  Use Config.Now to fix any timestamps the client adds.
+func CanonicalJSON(v interface{}) (b []byte, err error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.CanonicalJSON - could not marshal")
+		return
+	}
+
+	//decoding into generic maps and encoding again sorts every object's keys
+	var generic interface{}
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	err = d.Decode(&generic)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.CanonicalJSON - could not decode")
+		return
+	}
+
+	var buf bytes.Buffer
+	e := json.NewEncoder(&buf)
+	e.SetEscapeHTML(false)
+	e.SetIndent("", "  ")
+	err = e.Encode(generic)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.CanonicalJSON - could not encode")
+		return
+	}
+
+	b = buf.Bytes()
+	return
+}
@@ -0,0 +1,566 @@
+package xpo
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coreymgilmore/xpologistics/transport"
+	"github.com/pkg/errors"
+)
+
+//Mode is the XPO environment a Client sends requests to
+//Test requests are accepted by XPO but nothing is actually booked.  Production requests book real pickups
+//and real trucks show up.  The mode is set when a Client is created and cannot be changed after.
+type Mode int
+
+//modes
+const (
+	ModeTest Mode = iota
+	ModeProduction
+)
+
+//String returns a readable name for the mode for use in logging and errors
+func (m Mode) String() string {
+	switch m {
+	case ModeTest:
+		return "test"
+	case ModeProduction:
+		return "production"
+	default:
+		return "unknown"
+	}
+}
+
+//MarshalText encodes the mode as "test" or "production" so saved pickups, events, and audit records are readable
+func (m Mode) MarshalText() ([]byte, error) {
+	if m != ModeTest && m != ModeProduction {
+		return nil, errors.New("xpo - invalid mode " + strconv.Itoa(int(m)))
+	}
+
+	return []byte(m.String()), nil
+}
+
+//UnmarshalText parses "test" or "production", or 0 or 1 as older versions of this package saved the mode
+func (m *Mode) UnmarshalText(b []byte) error {
+	switch strings.ToLower(strings.TrimSpace(string(b))) {
+	case "test", "0":
+		*m = ModeTest
+	case "production", "1":
+		*m = ModeProduction
+	default:
+		return errors.New("xpo - invalid mode " + strconv.Quote(string(b)))
+	}
+
+	return nil
+}
+
+//UnmarshalJSON parses the mode as a string or, as saved by older versions of this package, a number
+func (m *Mode) UnmarshalJSON(b []byte) error {
+	s := strings.TrimSpace(string(b))
+	if s == "null" {
+		return nil
+	}
+
+	return m.UnmarshalText([]byte(strings.Trim(s, `"`)))
+}
+
+//testMode returns the value XPO expects in the testMode query parameter
+func (m Mode) testMode() string {
+	if m == ModeProduction {
+		return "N"
+	}
+
+	return "Y"
+}
+
+//ErrProductionNotAllowed is returned when a client in production mode makes a request without production
+//being explicitly allowed.
+var ErrProductionNotAllowed = errors.New("xpo - production mode requested but AllowProduction is not set")
+
+//ErrReadOnly is returned when a read only client tries to book something, like a pickup
+var ErrReadOnly = errors.New("xpo - client is read only and cannot book")
+
+//Config holds the settings used to build a Client
+type Config struct {
+	//required, unless CredentialsProvider is set
+	Username    string //website login
+	Password    string
+	AccessToken string //used to retrieve bearer tokens, keep this secret
+
+	//CredentialsProvider loads credentials at runtime, like from a secrets manager, instead of the fields above
+	CredentialsProvider CredentialsProvider
+
+	//optional
+	Tenant          string         //the customer this client is for when serving many XPO accounts, see Registry
+	Mode            Mode           //defaults to ModeTest
+	AllowProduction bool           //must be true to make requests with ModeProduction
+	ReadOnly        bool           //refuse requests that book anything, for credentials handed to tracking-only apps
+	Timeout         time.Duration  //defaults to 10 seconds
+	AuditSink       AuditSink      //receives a record of every request made
+	PickupStore     PickupStore    //saves a history of pickup requests
+	EventPublisher  EventPublisher //receives pickup booked, status changed, and other events
+	HTTPClient      *http.Client   //used to make requests, Timeout is ignored when this is set
+	MaxRetries      int            //retry requests XPO didn't process up to this many times, see RetryTransport
+	Backoff         Backoff        //delay between retries, defaults to exponential backoff with jitter
+
+	//EndpointTimeouts sets dial, TLS, response header, and body timeouts per endpoint, keyed by endpoint name
+	//Endpoints not listed, and fields left zero, use Timeout (or HTTPClient.Timeout) as the total time allowed.
+	//See TimeoutTransport.
+	EndpointTimeouts map[string]Timeouts
+
+	//HTTPOptions tunes connection pooling and the http version, ignored when HTTPClient is set
+	HTTPOptions HTTPOptions
+
+	//BaseURL replaces XPO's https://api.ltl.xpo.com in every request, like to use a simulator (see cmd/xpo-sim)
+	//Leave this empty to use XPO.
+	BaseURL string
+
+	//FallbackBaseURL is where requests go after XPO fails repeatedly, like a proxy or XPO's alternate host
+	//Requests fail back to XPO automatically once it recovers, see FailoverTransport.  Bearer tokens aren't
+	//sent to the fallback unless FallbackForwardCredentials is set, and tokens are only ever requested from XPO.
+	FallbackBaseURL            string
+	FallbackForwardCredentials bool
+
+	//RetryBudgets overrides MaxRetries and Backoff per endpoint, keyed by endpoint name (EndpointPickup, etc.)
+	//Retries are only enabled when MaxRetries is set.
+	RetryBudgets map[string]RetryBudget
+
+	//StreamRequests encodes every request body straight to the connection, see Endpoint.Stream
+	//This keeps memory flat in workers sending many large batches at once.
+	StreamRequests bool
+
+	//CompressRequests gzips large request bodies to save upload time on slow connections
+	//If XPO rejects a compressed body it is sent again uncompressed and compression is turned off for the client.
+	CompressRequests bool
+
+	//TokenStore saves bearer tokens for reuse, defaults to an in memory store for this client
+	TokenStore TokenStore
+
+	//RequestsPerSecond limits how fast this client sends requests to XPO, 0 means no limit
+	//Give each tenant its own limit so one customer can't use up XPO's rate limit for everyone.
+	RequestsPerSecond float64
+
+	//Now returns the current time, defaults to time.Now
+	//Set this to a fixed time in tests so timestamps in audit records and stored pickups don't change.
+	Now func() time.Time
+
+	//Messages replaces XPO's error description for a fault code with your own message
+	//Use this to show users consistent English messages, XPO's descriptions vary in language and wording.
+	Messages map[string]string
+}
+
+//Client makes requests to the XPO API
+//A client is bound to one mode for its whole life so it is always known where a pickup was
+//booked.  Create one with NewClient().
+type Client struct {
+	tenant      string
+	credentials CredentialsProvider
+	baseURL     string
+
+	mode            Mode
+	allowProduction bool
+	readOnly        bool
+
+	transport *transport.Transport
+
+	tokenStore  TokenStore
+	latency     latencyRecorder
+	auditSink   AuditSink
+	events      EventPublisher
+	pickupStore PickupStore
+	messages    map[string]string
+	stream      bool
+	gzip        atomic.Bool
+	now         func() time.Time
+
+	trackedMu sync.Mutex
+	tracked   map[string]string //last status seen for each PRO tracked, for EventStatusChanged
+}
+
+//NewClient builds a client from the given config
+func NewClient(cfg Config) (c *Client, err error) {
+	if cfg.CredentialsProvider == nil && (cfg.Username == "" || cfg.Password == "" || cfg.AccessToken == "") {
+		err = errors.New("xpo.NewClient - username, password, and access token, or a credentials provider, are required")
+		return
+	}
+
+	if cfg.Mode != ModeTest && cfg.Mode != ModeProduction {
+		err = errors.New("xpo.NewClient - invalid mode")
+		return
+	}
+
+	if cfg.BaseURL != "" {
+		u, parseErr := url.Parse(cfg.BaseURL)
+		if parseErr != nil || u.Scheme == "" || u.Host == "" {
+			err = errors.New("xpo.NewClient - base url must be an absolute url")
+			return
+		}
+	}
+
+	if cfg.FallbackBaseURL != "" {
+		u, parseErr := url.Parse(cfg.FallbackBaseURL)
+		if parseErr != nil || u.Scheme == "" || u.Host == "" {
+			err = errors.New("xpo.NewClient - fallback base url must be an absolute url")
+			return
+		}
+	}
+
+	c = newClient(cfg)
+	return
+}
+
+//newClient builds a client without checking the config
+//This is used directly by the package level functions which check the config at request time.
+func newClient(cfg Config) (c *Client) {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if cfg.TokenStore == nil {
+		cfg.TokenStore = NewMemoryTokenStore()
+	}
+	if cfg.CredentialsProvider == nil {
+		cfg.CredentialsProvider = StaticCredentials{
+			Username:    cfg.Username,
+			Password:    cfg.Password,
+			AccessToken: cfg.AccessToken,
+		}
+	}
+
+	c = &Client{
+		tenant:          cfg.Tenant,
+		credentials:     cfg.CredentialsProvider,
+		baseURL:         strings.TrimSuffix(cfg.BaseURL, "/"),
+		mode:            cfg.Mode,
+		allowProduction: cfg.AllowProduction,
+		readOnly:        cfg.ReadOnly,
+		auditSink:       cfg.AuditSink,
+		events:          cfg.EventPublisher,
+		pickupStore:     cfg.PickupStore,
+		tokenStore:      cfg.TokenStore,
+		messages:        cfg.Messages,
+		stream:          cfg.StreamRequests,
+		now:             cfg.Now,
+		tracked:         map[string]string{},
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: transport.NewHTTPTransport(cfg.HTTPOptions),
+		}
+	}
+
+	//retries go through failover so a retry can go to the fallback once the primary is failing
+	rt := httpClient.Transport
+	if fallback, err := url.Parse(cfg.FallbackBaseURL); cfg.FallbackBaseURL != "" && err == nil {
+		rt = &FailoverTransport{
+			Next:               rt,
+			Fallback:           fallback,
+			ForwardCredentials: cfg.FallbackForwardCredentials,
+		}
+	}
+	if cfg.MaxRetries > 0 {
+		rt = &RetryTransport{
+			Next:       rt,
+			MaxRetries: cfg.MaxRetries,
+			Backoff:    cfg.Backoff,
+			Budgets:    cfg.RetryBudgets,
+		}
+	}
+
+	//per endpoint timeouts replace the client's overall timeout, which would cut off slow endpoints
+	if cfg.EndpointTimeouts != nil {
+		rt = &transport.TimeoutTransport{
+			Next:      rt,
+			Default:   transport.Timeouts{Total: httpClient.Timeout},
+			Endpoints: cfg.EndpointTimeouts,
+		}
+	}
+
+	//copy the http client so the caller's isn't changed
+	if rt != httpClient.Transport {
+		wrapped := *httpClient
+		wrapped.Transport = rt
+		if cfg.EndpointTimeouts != nil {
+			wrapped.Timeout = 0
+		}
+		httpClient = &wrapped
+	}
+
+	c.gzip.Store(cfg.CompressRequests)
+
+	c.transport = &transport.Transport{
+		Client:  httpClient,
+		Observe: c.observe,
+		Limiter: transport.NewLimiter(cfg.RequestsPerSecond),
+	}
+	return
+}
+
+//ReadOnly checks if this client refuses to book anything
+func (c *Client) ReadOnly() bool {
+	return c.readOnly
+}
+
+//Mode returns the mode this client sends requests with
+func (c *Client) Mode() Mode {
+	return c.mode
+}
+
+//wrapMode adds the client's mode to an error
+//Every error returned from a request has the mode so it is provable which environment a request was made against.
+func (c *Client) wrapMode(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return errors.Wrap(err, "mode "+c.mode.String())
+}
+
+//Tenant returns the tenant this client was created for
+func (c *Client) Tenant() string {
+	return c.tenant
+}
+
+//logf logs a message with the client's mode, and tenant if it has one
+func (c *Client) logf(format string, v ...interface{}) {
+	prefix := "xpo (" + c.mode.String() + ") "
+	if c.tenant != "" {
+		prefix = "xpo (" + c.tenant + ", " + c.mode.String() + ") "
+	}
+
+	log.Printf(prefix+format, v...)
+}
+
+//pickupURL returns the pickup api url for the client's mode
+func (c *Client) pickupURL() string {
+	return c.url(xpoPickupURL) + "?testMode=" + c.mode.testMode()
+}
+
+//url returns an XPO url with the client's base url, if one was given, in place of XPO's
+func (c *Client) url(u string) string {
+	if c.baseURL == "" {
+		return u
+	}
+
+	return c.baseURL + strings.TrimPrefix(u, xpoBaseURL)
+}
+
+//RequestPickup performs the API call to schedule a pickup
+//requests to XPO require two steps: getting a token, and making the pickup request.  Why? b/c dumb.
+func (c *Client) RequestPickup(ctx context.Context, pri *PickupRqstInfo) (response SuccessfulPickupResponse, err error) {
+	response, _, err = c.requestPickup(ctx, pri, pickupAttempt{})
+	return
+}
+
+//pickupAttempt changes how requestPickup saves a pickup to the client's PickupStore
+type pickupAttempt struct {
+	recordID string //update this stored pickup, saved by an earlier attempt, instead of saving a new one
+	queued   bool   //the pickup is queued to be sent again if XPO can't be reached, so it is saved as queued
+
+	//unvalidated only totals the pickup, like the package level RequestPickup always did, so pickups it sent
+	//before are still sent and left for XPO to accept or reject
+	unvalidated bool
+}
+
+//requestPickup requests a pickup, returning the id of the stored pickup if the client has a PickupStore
+func (c *Client) requestPickup(ctx context.Context, pri *PickupRqstInfo, a pickupAttempt) (response SuccessfulPickupResponse, recordID string, err error) {
+	//a pickup saved by an earlier attempt is updated however this attempt turns out, even if it is invalid now
+	recordID = a.recordID
+	if c.pickupStore != nil && recordID != "" {
+		defer func() {
+			c.updatePickup(context.WithoutCancel(ctx), recordID, response, err, a.queued)
+		}()
+	}
+
+	if a.unvalidated {
+		pri.total()
+	} else {
+		err = pri.prepare()
+		if err != nil {
+			err = c.wrapMode(errors.Wrap(err, "xpo.RequestPickup - invalid pickup request"))
+			return
+		}
+	}
+
+	//these don't stop the pickup, they are returned so the pickup can be flagged for someone to look at
+	reviewWarnings := pri.Warnings()
+	for _, w := range reviewWarnings {
+		c.logf("xpo.RequestPickup - review: %s", w)
+	}
+	defer func() {
+		response.ReviewWarnings = reviewWarnings
+	}()
+
+	//make sure we are allowed to use this mode and book before saving anything
+	err = c.checkAllowed(c.pickupEndpoint())
+	if err != nil {
+		err = c.wrapMode(err)
+		return
+	}
+
+	//save the pickup before it is requested so there is always a record of what was sent to XPO
+	if c.pickupStore != nil && recordID == "" {
+		recordID, err = c.savePickup(ctx, pri)
+		if err != nil {
+			err = c.wrapMode(errors.Wrap(err, "xpo.RequestPickup - could not save pickup"))
+			return
+		}
+
+		//the result is saved even if ctx was canceled since the pickup may have been booked anyway
+		defer func() {
+			c.updatePickup(context.WithoutCancel(ctx), recordID, response, err, a.queued)
+		}()
+	}
+
+	//add the pickup request info to the pickup container object
+	pr := PickupRequest{
+		PickupRqstInfo: *pri,
+	}
+
+	response, err = Call[PickupRequest, SuccessfulPickupResponse](ctx, c, c.pickupEndpoint(), pr)
+	response.Metadata = pri.Metadata
+	for _, w := range response.AllWarnings() {
+		c.logf("xpo.RequestPickup - warning for %s: %s", response.Data.ConfirmationNbr, w)
+	}
+
+	if err == nil {
+		c.publish(ctx, Event{
+			Type:            EventPickupBooked,
+			RecordID:        recordID,
+			ConfirmationNbr: response.Data.ConfirmationNbr,
+			PickupID:        response.Data.PickupID,
+			Metadata:        pri.Metadata,
+		})
+	}
+
+	//pickup request successful
+	//response data will have confirmation number
+	//an email should also have been sent to the requester email
+	return
+}
+
+//BuildPickupRequest prepares the exact http request RequestPickup would send, without sending it
+//The pickup request is totaled, normalized, and validated the same as in RequestPickup.  See BuildRequest.
+func (c *Client) BuildPickupRequest(ctx context.Context, pri *PickupRqstInfo) (req *http.Request, err error) {
+	err = pri.prepare()
+	if err != nil {
+		err = c.wrapMode(errors.Wrap(err, "xpo.BuildPickupRequest - invalid pickup request"))
+		return
+	}
+
+	pr := PickupRequest{
+		PickupRqstInfo: *pri,
+	}
+	return BuildRequest(ctx, c, c.pickupEndpoint(), pr)
+}
+
+//pickupEndpoint returns the pickup endpoint for the client's mode
+func (c *Client) pickupEndpoint() Endpoint {
+	return Endpoint{
+		Name:    EndpointPickup,
+		URL:     c.pickupURL(),
+		Booking: true,
+	}
+}
+
+//prepare calculates totals, cleans up, and validates a pickup request before it is sent
+func (pri *PickupRqstInfo) prepare() error {
+	pri.total()
+
+	//catch problems before XPO does
+	pri.normalize()
+	return pri.Validate()
+}
+
+//total calculates total weight, pallet count, number of pieces for all items
+func (pri *PickupRqstInfo) total() {
+	var totalSkids uint
+	var totalPieces uint
+	var totalWeight uint
+	for _, v := range pri.PkupItem {
+		totalSkids += v.PalletCnt
+		totalPieces += v.LoosePiecesCnt
+		totalWeight += v.TotWeight.Weight
+	}
+	pri.TotPalletCnt = totalSkids
+	pri.TotLoosePieceCnt = totalPieces
+	pri.TotWeight.Weight = totalWeight
+	return
+}
+
+//Ping checks that XPO is reachable and our credentials are valid
+//This retrieves a new bearer token and nothing else, nothing is booked, so it is safe to use as a readiness probe.
+func (c *Client) Ping(ctx context.Context) (err error) {
+	creds, err := c.getCredentials(ctx)
+	if err != nil {
+		err = c.wrapMode(errors.Wrap(err, "xpo.Ping - could not get token"))
+		return
+	}
+
+	_, err = c.fetchToken(ctx, creds)
+	if err != nil {
+		err = c.wrapMode(errors.Wrap(err, "xpo.Ping - could not get token"))
+		return
+	}
+
+	return
+}
+
+//observe records latency stats for each request made by the transport
+//A request counts as failed for stats if it could not be made or XPO returned an error status.
+func (c *Client) observe(endpoint string, d time.Duration, statusCode int, err error) {
+	c.latency.record(endpoint, d, err != nil || statusCode >= http.StatusBadRequest)
+	return
+}
+
+//getRequestToken gets a "bearer" token we can use to make a request to the pickup api
+//The token is reused until shortly before it expires, see RunTokenRefresher.
+func (c *Client) getRequestToken(ctx context.Context) (bearerToken string, err error) {
+	creds, err := c.getCredentials(ctx)
+	if err != nil {
+		return
+	}
+
+	if t, ok := c.cachedToken(ctx, creds); ok && t.fresh(c.now()) {
+		return t.BearerToken, nil
+	}
+
+	return c.fetchToken(ctx, creds)
+}
+
+//fetchToken requests a new "bearer" token from XPO and caches it
+//We request this temporary token using our permanent access token.
+func (c *Client) fetchToken(ctx context.Context, creds Credentials) (bearerToken string, err error) {
+	//audit with the password left out of the payload
+	var statusCode int
+	start := c.now()
+	defer func() {
+		c.audit(start, EndpointToken, payloadHash(transport.TokenForm(creds, false)), statusCode, "", err)
+	}()
+
+	token, res, err := c.transport.Token(ctx, c.url(xpoTokenURL), creds)
+	statusCode = res.StatusCode
+	if m, ok := maintenance(res, c.now()); ok {
+		err = m
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	c.saveToken(ctx, creds, token, start)
+	bearerToken = token.BearerToken
+	return
+}
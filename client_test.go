@@ -0,0 +1,53 @@
+package xpo
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestModeJSON(t *testing.T) {
+	b, err := json.Marshal(Event{Mode: ModeProduction})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var e struct {
+		Mode string `json:"mode"`
+	}
+	json.Unmarshal(b, &e)
+	if e.Mode != "production" {
+		t.Errorf("got mode %q in %s, want production", e.Mode, b)
+	}
+
+	//pickups and events saved before the mode was encoded as text have it as a number
+	tests := []struct {
+		json string
+		want Mode
+	}{
+		{`"test"`, ModeTest},
+		{`"production"`, ModeProduction},
+		{`"PRODUCTION"`, ModeProduction},
+		{`0`, ModeTest},
+		{`1`, ModeProduction},
+		{`"1"`, ModeProduction},
+	}
+	for _, tt := range tests {
+		m := Mode(-1)
+		if err := json.Unmarshal([]byte(tt.json), &m); err != nil || m != tt.want {
+			t.Errorf("unmarshal %s got %v %v, want %v", tt.json, m, err, tt.want)
+		}
+	}
+
+	var r PickupRecord
+	if err := json.Unmarshal([]byte(`{"ID":"abc","Mode":1}`), &r); err != nil || r.Mode != ModeProduction {
+		t.Errorf("got %v %v for a record saved with a numeric mode", r.Mode, err)
+	}
+
+	var m Mode
+	if err := json.Unmarshal([]byte(`"staging"`), &m); err == nil {
+		t.Error("got no error for an unknown mode")
+	}
+	if _, err := json.Marshal(Mode(5)); err == nil {
+		t.Error("got no error marshaling an invalid mode")
+	}
+}
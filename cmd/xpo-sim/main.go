@@ -0,0 +1,327 @@
+//xpo-sim is a fake XPO API for staging environments and load tests
+//It serves XPO's token, pickup request, and tracking endpoints so a client can be run without XPO
+//credentials.  Any username, password, and access token are accepted.  Point a client at it with
+//Config.BaseURL:
+//
+//	xpo-sim -addr :8080 -latency 300ms -fail-rate 0.05 -fault-rate 0.05
+//	client, err := xpo.NewClient(xpo.Config{..., BaseURL: "http://localhost:8080"})
+//
+//Failures are returned as XPO returns them, 5xx responses for -fail-rate and xml faults, picked at random
+//from -faults, for -fault-rate.  -maintenance serves XPO's maintenance page for every request.
+package main
+
+import (
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"io"
+	"io/ioutil"
+	"log"
+	mathrand "math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreymgilmore/xpologistics"
+	"github.com/coreymgilmore/xpologistics/transport"
+)
+
+//paths served, the same as XPO's
+const (
+	tokenPath    = "/token"
+	pickupPath   = "/pickuprequest/1.0/cust-pickup-requests"
+	trackingPath = "/tracking/1.0/shipments/shipment-status-details"
+)
+
+//maintenancePage is returned for every request when -maintenance is set
+const maintenancePage = `<html><head><title>Scheduled Maintenance</title></head><body><h1>XPO is down for scheduled maintenance</h1><p>Please try again later.</p></body></html>`
+
+//sim holds the simulator settings and the tokens it has issued
+type sim struct {
+	latency       time.Duration
+	jitter        time.Duration
+	failRate      float64
+	faultRate     float64
+	faults        []string
+	maintenance   time.Duration
+	tokenLifetime time.Duration
+
+	mu     sync.Mutex
+	tokens map[string]time.Time //bearer token to expiration
+	rand   *mathrand.Rand
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	latency := flag.Duration("latency", 0, "time to wait before responding")
+	jitter := flag.Duration("jitter", 0, "random extra time, up to this, to wait before responding")
+	failRate := flag.Float64("fail-rate", 0, "fraction of requests, 0 to 1, answered with a 503")
+	faultRate := flag.Float64("fault-rate", 0, "fraction of requests, 0 to 1, answered with an xml fault")
+	faults := flag.String("faults", xpo.ErrCodeBackendTimeout+","+xpo.ErrCodeThrottled, "comma separated fault codes to pick from")
+	maintenance := flag.Duration("maintenance", 0, "serve the maintenance page with this Retry-After for every request")
+	tokenLifetime := flag.Duration("token-lifetime", 12*time.Hour, "how long issued bearer tokens are valid")
+	flag.Parse()
+
+	s := &sim{
+		latency:       *latency,
+		jitter:        *jitter,
+		failRate:      *failRate,
+		faultRate:     *faultRate,
+		faults:        strings.Split(*faults, ","),
+		maintenance:   *maintenance,
+		tokenLifetime: *tokenLifetime,
+		tokens:        map[string]time.Time{},
+		rand:          mathrand.New(mathrand.NewSource(time.Now().UnixNano())),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(tokenPath, s.handle(http.MethodPost, s.token))
+	mux.HandleFunc(pickupPath, s.handle(http.MethodPost, s.pickup))
+	mux.HandleFunc(trackingPath, s.handle(http.MethodGet, s.tracking))
+
+	log.Println("xpo-sim listening on", *addr)
+	log.Fatalln(http.ListenAndServe(*addr, mux))
+}
+
+//handle wraps an endpoint with the simulated latency, outages, and faults
+func (s *sim) handle(method string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(s.delay())
+
+		if r.Method != method {
+			writeFault(w, http.StatusMethodNotAllowed, xpo.ErrCodeNoMatchingResource, "No matching resource found for given API Request")
+			return
+		}
+
+		if s.maintenance > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(s.maintenance.Seconds())))
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			io.WriteString(w, maintenancePage)
+			return
+		}
+
+		fail, fault := s.roll()
+		switch {
+		case fail:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		case fault != "":
+			f, _ := xpo.LookupFaultCode(fault)
+			writeFault(w, faultStatus(fault), fault, f.Explanation)
+			return
+		}
+
+		h(w, r)
+	}
+}
+
+//token issues a bearer token for any credentials
+func (s *sim) token(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil || !strings.HasPrefix(r.Header.Get("Authorization"), "Basic ") || r.PostForm.Get("username") == "" || r.PostForm.Get("password") == "" {
+		writeFault(w, http.StatusUnauthorized, xpo.ErrCodeInvalidCredentials, "Invalid Credentials. Make sure you have given the correct access token")
+		return
+	}
+
+	t := transport.TokenResponse{
+		BearerToken:  randomID(16),
+		RefreshToken: randomID(16),
+		Scope:        "default",
+		TokenType:    "Bearer",
+		ExpiresIn:    uint(s.tokenLifetime.Seconds()),
+	}
+
+	s.mu.Lock()
+	s.tokens[t.BearerToken] = time.Now().Add(s.tokenLifetime)
+	s.mu.Unlock()
+
+	writeJSON(w, t)
+}
+
+//pickup books a fake pickup
+func (s *sim) pickup(w http.ResponseWriter, r *http.Request) {
+	if !s.validToken(r) {
+		writeFault(w, http.StatusUnauthorized, xpo.ErrCodeInvalidCredentials, "Invalid Credentials. Make sure you have given the correct access token")
+		return
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		writeFault(w, http.StatusBadRequest, "400", "could not read request body")
+		return
+	}
+
+	var pr xpo.PickupRequest
+	err = json.Unmarshal(body, &pr)
+	if err != nil {
+		writeFault(w, http.StatusBadRequest, "400", "could not unmarshal pickup request: "+err.Error())
+		return
+	}
+	if len(pr.PickupRqstInfo.PkupItem) == 0 || pr.PickupRqstInfo.PkupDate.IsZero() {
+		writeFault(w, http.StatusBadRequest, "400", "pickup date and at least one item are required")
+		return
+	}
+
+	response := xpo.SuccessfulPickupResponse{
+		Code:                 "200",
+		TransactionTimestamp: xpo.NewTime(time.Now()),
+		Data: xpo.ConfirmationNumber{
+			PickupID:        randomID(8),
+			ConfirmationNbr: xpo.ConfirmationNbr("SIM" + strings.ToUpper(randomID(4))),
+		},
+	}
+	log.Println("booked pickup", response.Data.ConfirmationNbr, "for", pr.PickupRqstInfo.PkupDate, "testMode="+r.URL.Query().Get("testMode"))
+
+	writeJSON(w, response)
+}
+
+//tracking returns a shipment in transit, picked up yesterday, for each PRO
+func (s *sim) tracking(w http.ResponseWriter, r *http.Request) {
+	if !s.validToken(r) {
+		writeFault(w, http.StatusUnauthorized, xpo.ErrCodeInvalidCredentials, "Invalid Credentials. Make sure you have given the correct access token")
+		return
+	}
+
+	origin := xpo.Terminal{SicCd: "XCH", City: "Chicago", StateCd: "IL"}
+	destination := xpo.Terminal{SicCd: "XNY", City: "Newark", StateCd: "NJ"}
+	now := time.Now()
+
+	var response xpo.TrackingResponse
+	response.Code = "200"
+	response.TransactionTimestamp = xpo.NewTime(now)
+	for _, ref := range strings.Split(r.URL.Query().Get("referenceNumbers"), ",") {
+		pro, err := xpo.ParsePRO(ref)
+		if err != nil {
+			continue
+		}
+
+		response.Data.Shipments = append(response.Data.Shipments, xpo.Shipment{
+			ReferenceNbr:          ref,
+			PRO:                   pro,
+			Status:                xpo.ShipmentStatus{Code: "IN_TRANSIT", Description: "In Transit"},
+			EstimatedDeliveryDate: xpo.NewDate(now.AddDate(0, 0, 2)),
+			Origin:                origin,
+			Destination:           destination,
+			Events: []xpo.TrackingEvent{
+				{Time: xpo.NewTime(now.AddDate(0, 0, -1)), Code: "PICKED_UP", Description: "Picked Up", Terminal: origin},
+				{Time: xpo.NewTime(now.Add(-2 * time.Hour)), Code: "IN_TRANSIT", Description: "Departed Terminal", Terminal: origin},
+			},
+		})
+	}
+
+	writeJSON(w, response)
+}
+
+//validToken checks that a request has a bearer token issued by the simulator that hasn't expired
+func (s *sim) validToken(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expires, ok := s.tokens[token]
+	return ok && time.Now().Before(expires)
+}
+
+//delay returns how long to wait before responding
+func (s *sim) delay() time.Duration {
+	if s.jitter <= 0 {
+		return s.latency
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.latency + time.Duration(s.rand.Int63n(int64(s.jitter)))
+}
+
+//roll picks whether a request fails, and if so how
+func (s *sim) roll() (fail bool, fault string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := s.rand.Float64()
+	switch {
+	case n < s.failRate:
+		return true, ""
+	case n < s.failRate+s.faultRate && len(s.faults) > 0:
+		return false, strings.TrimSpace(s.faults[s.rand.Intn(len(s.faults))])
+	}
+
+	return false, ""
+}
+
+//faultStatus returns the http status XPO sends with a fault code
+func faultStatus(code string) int {
+	switch code {
+	case xpo.ErrCodeThrottled, xpo.ErrCodeThrottledAPI, xpo.ErrCodeThrottledApplication, xpo.ErrCodeThrottledSubscription:
+		return http.StatusTooManyRequests
+	case xpo.ErrCodeAuthFailure, xpo.ErrCodeInvalidCredentials, xpo.ErrCodeMissingCredentials:
+		return http.StatusUnauthorized
+	case xpo.ErrCodeAPIBlocked, xpo.ErrCodeResourceForbidden, xpo.ErrCodeSubscriptionInactive, xpo.ErrCodeScopeNotAllowed:
+		return http.StatusForbidden
+	case xpo.ErrCodeNoMatchingResource:
+		return http.StatusNotFound
+	default:
+		return http.StatusServiceUnavailable
+	}
+}
+
+//readBody reads a request body, decompressing it if it was gzipped
+func readBody(r *http.Request) ([]byte, error) {
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	return ioutil.ReadAll(io.LimitReader(body, transport.MaxResponseSize))
+}
+
+//writeFault writes an xml fault the way XPO does
+func writeFault(w http.ResponseWriter, status int, code, description string) {
+	f := struct {
+		XMLName     xml.Name `xml:"am:fault"`
+		Namespace   string   `xml:"xmlns:am,attr"`
+		Code        string   `xml:"am:code"`
+		Type        string   `xml:"am:type"`
+		Message     string   `xml:"am:message"`
+		Description string   `xml:"am:description"`
+	}{
+		Namespace:   "http://wso2.org/apimanager",
+		Code:        code,
+		Type:        "Status report",
+		Message:     http.StatusText(status),
+		Description: description,
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	xml.NewEncoder(w).Encode(f)
+}
+
+//writeJSON writes a successful json response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Println("could not write response", err)
+	}
+}
+
+//randomID returns n random bytes hex encoded
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,35 @@
+//xpo is a command line tool for recovering from problems with XPO pickups
+//
+//	xpo replay [-date 2006-01-02] [-production] [-dry-run] file.json...
+//
+//Credentials are read from the XPO_USERNAME, XPO_PASSWORD, and XPO_ACCESS_TOKEN environment variables so
+//they don't end up in shell history.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+//usage is printed when no, or an unknown, subcommand is given
+const usage = `usage: xpo <command> [flags]
+
+commands:
+  replay   request saved pickups again, like ones that failed or were stuck in an outbox queue
+
+run xpo <command> -h for the flags of a command`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "replay":
+		os.Exit(replay(os.Args[2:]))
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/coreymgilmore/xpologistics"
+)
+
+//replay requests the pickups saved in the given files again
+//Each file holds one pickup as a stored PickupRecord, a QueuedPickup from a DirPickupQueue, or a plain
+//PickupRqstInfo.  Stored pickups that didn't fail, and queued pickups, may already be booked so they are only
+//requested with -confirmed.  The exit code is 1 if any pickup failed.
+func replay(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	date := fs.String("date", "", "move each pickup to this day, YYYY-MM-DD, keeping its ready and close times")
+	production := fs.Bool("production", false, "book real pickups instead of sending to XPO's test mode")
+	dryRun := fs.Bool("dry-run", false, "print the pickups that would be requested without sending them")
+	baseURL := fs.String("base-url", "", "send requests here instead of XPO, like to xpo-sim")
+	confirmed := fs.Bool("confirmed", false, "also request pickups that may be booked already, after checking with XPO that they aren't")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "xpo replay - no files given")
+		return 2
+	}
+
+	opts := xpo.ReplayOptions{
+		Confirmed: *confirmed,
+	}
+	if *date != "" {
+		d, err := time.ParseInLocation(xpo.DateLayout, *date, time.Local)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "xpo replay - invalid date, use YYYY-MM-DD:", err)
+			return 2
+		}
+		opts.PkupDate = xpo.NewDate(d)
+	}
+
+	var records []xpo.PickupRecord
+	for _, f := range fs.Args() {
+		r, err := readRecord(f)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "xpo replay -", f+":", err)
+			return 2
+		}
+		records = append(records, r)
+	}
+
+	if *dryRun {
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		for _, r := range records {
+			pri, err := r.ReplayRequest(opts)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "xpo replay -", r.ID+":", err)
+				return 1
+			}
+			e.Encode(pri)
+		}
+		return 0
+	}
+
+	mode := xpo.ModeTest
+	if *production {
+		mode = xpo.ModeProduction
+	}
+
+	c, err := xpo.NewClient(xpo.Config{
+		Username:        os.Getenv("XPO_USERNAME"),
+		Password:        os.Getenv("XPO_PASSWORD"),
+		AccessToken:     os.Getenv("XPO_ACCESS_TOKEN"),
+		Mode:            mode,
+		AllowProduction: *production,
+		BaseURL:         *baseURL,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "xpo replay -", err)
+		return 2
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	code := 0
+	for _, result := range c.Replay(ctx, records, opts) {
+		f := fs.Arg(result.Index)
+		if result.Err != nil {
+			fmt.Printf("%s\tfailed\t%v\n", f, result.Err)
+			code = 1
+			continue
+		}
+		fmt.Printf("%s\tbooked\t%s\n", f, result.Value.Data.ConfirmationNbr)
+	}
+
+	return code
+}
+
+//readRecord reads a saved pickup from a json file
+//The record id defaults to the file name so replayed pickups can be traced back to the file.
+func readRecord(path string) (r xpo.PickupRecord, err error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(b, &fields)
+	if err != nil {
+		return
+	}
+
+	switch {
+	case fields["QueuedAt"] != nil:
+		var q xpo.QueuedPickup
+		err = json.Unmarshal(b, &q)
+		r = xpo.PickupRecord{
+			ID:         q.ID,
+			Status:     xpo.PickupStatusQueued,
+			Request:    q.Request,
+			Accounting: q.Accounting,
+			Metadata:   q.Metadata,
+		}
+	case fields["Request"] != nil:
+		err = json.Unmarshal(b, &r)
+	default:
+		err = json.Unmarshal(b, &r.Request)
+	}
+
+	if r.ID == "" {
+		r.ID = filepath.Base(path)
+	}
+	return
+}
@@ -0,0 +1,40 @@
+package xpo
+
+import (
+	"strings"
+)
+
+//ContactRole is what a contact on a pickup is responsible for
+type ContactRole string
+
+//contact roles
+const (
+	ContactRoleDock       ContactRole = "DOCK SUPERVISOR"
+	ContactRoleAfterHours ContactRole = "AFTER HOURS"
+	ContactRoleBilling    ContactRole = "BILLING"
+	ContactRoleShipping   ContactRole = "SHIPPING"
+)
+
+//RoleContact is a contact for a pickup along with their role
+type RoleContact struct {
+	Role    ContactRole
+	Contact Contact
+}
+
+//String formats the contact for the pickup remarks
+//ex: DOCK SUPERVISOR: Jane Doe 503-5551212 jane@example.com
+func (r RoleContact) String() string {
+	parts := []string{r.Contact.FullName}
+	if p := r.Contact.Phone.wire().PhoneNbr; p != "" {
+		parts = append(parts, p)
+	}
+	if r.Contact.Email.EmailAddr != "" {
+		parts = append(parts, r.Contact.Email.EmailAddr)
+	}
+
+	s := strings.TrimSpace(strings.Join(parts, " "))
+	if r.Role == "" {
+		return s
+	}
+	return string(r.Role) + ": " + s
+}
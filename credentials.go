@@ -0,0 +1,120 @@
+package xpo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/coreymgilmore/xpologistics/transport"
+	"github.com/pkg/errors"
+)
+
+//Credentials are the XPO account details used to get bearer tokens
+type Credentials = transport.Credentials
+
+//CredentialsProvider gets the XPO credentials to use when a new bearer token is needed
+//Use this to load credentials from a secrets manager at runtime, so they can be rotated without restarting,
+//instead of putting them in Config.  Credentials is called before every request so wrap slow providers with
+//CachedCredentials.
+//
+//A provider for HashiCorp Vault, using github.com/hashicorp/vault/api, might look like:
+//
+//	xpo.CredentialsFunc(func(ctx context.Context) (c xpo.Credentials, err error) {
+//		s, err := vault.KVv2("secret").Get(ctx, "xpo")
+//		if err != nil {
+//			return
+//		}
+//		c.Username, _ = s.Data["username"].(string)
+//		c.Password, _ = s.Data["password"].(string)
+//		c.AccessToken, _ = s.Data["access_token"].(string)
+//		return
+//	})
+//
+//and one for AWS Secrets Manager, using github.com/aws/aws-sdk-go-v2/service/secretsmanager, with the
+//credentials stored as a json secret with Username, Password, and AccessToken keys:
+//
+//	xpo.CredentialsFunc(func(ctx context.Context) (c xpo.Credentials, err error) {
+//		out, err := sm.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String("xpo")})
+//		if err != nil {
+//			return
+//		}
+//		err = json.Unmarshal([]byte(*out.SecretString), &c)
+//		return
+//	})
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+//CredentialsFunc adapts a func to a CredentialsProvider
+type CredentialsFunc func(ctx context.Context) (Credentials, error)
+
+//Credentials calls f
+func (f CredentialsFunc) Credentials(ctx context.Context) (Credentials, error) {
+	return f(ctx)
+}
+
+//StaticCredentials is a CredentialsProvider that always returns the same credentials
+type StaticCredentials Credentials
+
+//Credentials returns s
+func (s StaticCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	return Credentials(s), nil
+}
+
+//cachedCredentials remembers what a provider returned for a while
+type cachedCredentials struct {
+	provider CredentialsProvider
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	creds   Credentials
+	expires time.Time
+}
+
+//CachedCredentials wraps a provider so it is only called once every ttl
+//If the provider fails after credentials have been loaded once, the old credentials keep being used and the
+//provider is tried again on the next request.
+func CachedCredentials(p CredentialsProvider, ttl time.Duration) CredentialsProvider {
+	return &cachedCredentials{
+		provider: p,
+		ttl:      ttl,
+	}
+}
+
+//Credentials returns the cached credentials, loading them if they are stale
+func (c *cachedCredentials) Credentials(ctx context.Context) (creds Credentials, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Now().Before(c.expires) {
+		return c.creds, nil
+	}
+
+	creds, err = c.provider.Credentials(ctx)
+	if err != nil {
+		if c.creds.Username != "" {
+			return c.creds, nil
+		}
+		return
+	}
+
+	c.creds = creds
+	c.expires = time.Now().Add(c.ttl)
+	return
+}
+
+//getCredentials gets the client's credentials from its provider and makes sure they are complete
+func (c *Client) getCredentials(ctx context.Context) (creds Credentials, err error) {
+	creds, err = c.credentials.Credentials(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "xpo - could not get credentials")
+		return
+	}
+
+	if creds.Username == "" || creds.Password == "" || creds.AccessToken == "" {
+		err = errors.New("xpo - no credentials were provided via Config or SetCredentials()")
+		return
+	}
+
+	return
+}
@@ -0,0 +1,45 @@
+// Code generated by gen_errcodes.go from errcodes.csv; DO NOT EDIT.
+
+package xpo
+
+// known XPO fault codes
+const (
+	ErrCodeThrottled               = "900800"
+	ErrCodeThrottledAPI            = "900802"
+	ErrCodeThrottledApplication    = "900803"
+	ErrCodeThrottledSubscription   = "900804"
+	ErrCodeAuthFailure             = "900900"
+	ErrCodeInvalidCredentials      = "900901"
+	ErrCodeMissingCredentials      = "900902"
+	ErrCodeNoMatchingResource      = "900906"
+	ErrCodeAPIBlocked              = "900907"
+	ErrCodeResourceForbidden       = "900908"
+	ErrCodeSubscriptionInactive    = "900909"
+	ErrCodeScopeNotAllowed         = "900910"
+	ErrCodeBackendConnectFailed    = "101503"
+	ErrCodeBackendTimeout          = "101504"
+	ErrCodeBackendConnectionClosed = "101505"
+	ErrCodeBackendEndpointTimeout  = "101508"
+	ErrCodeEndpointUnavailable     = "303001"
+)
+
+// faultCodes holds the details of each known fault code
+var faultCodes = map[string]FaultCode{
+	ErrCodeThrottled:               {Code: ErrCodeThrottled, Name: "ErrCodeThrottled", Retryable: true, Explanation: "Too many requests were sent and XPO is throttling us. Slow down and try again."},
+	ErrCodeThrottledAPI:            {Code: ErrCodeThrottledAPI, Name: "ErrCodeThrottledAPI", Retryable: true, Explanation: "The API's request limit was reached. Slow down and try again."},
+	ErrCodeThrottledApplication:    {Code: ErrCodeThrottledApplication, Name: "ErrCodeThrottledApplication", Retryable: true, Explanation: "The request limit for our application was reached. Slow down and try again."},
+	ErrCodeThrottledSubscription:   {Code: ErrCodeThrottledSubscription, Name: "ErrCodeThrottledSubscription", Retryable: true, Explanation: "The request limit for our subscription was reached. Slow down and try again."},
+	ErrCodeAuthFailure:             {Code: ErrCodeAuthFailure, Name: "ErrCodeAuthFailure", Retryable: false, Explanation: "XPO could not authenticate the request for an unspecified reason."},
+	ErrCodeInvalidCredentials:      {Code: ErrCodeInvalidCredentials, Name: "ErrCodeInvalidCredentials", Retryable: false, Explanation: "The bearer token or credentials are invalid. Check the username, password, and access token."},
+	ErrCodeMissingCredentials:      {Code: ErrCodeMissingCredentials, Name: "ErrCodeMissingCredentials", Retryable: false, Explanation: "The request did not include credentials."},
+	ErrCodeNoMatchingResource:      {Code: ErrCodeNoMatchingResource, Name: "ErrCodeNoMatchingResource", Retryable: false, Explanation: "XPO does not have an endpoint matching the request's url and method."},
+	ErrCodeAPIBlocked:              {Code: ErrCodeAPIBlocked, Name: "ErrCodeAPIBlocked", Retryable: false, Explanation: "XPO has temporarily blocked the API."},
+	ErrCodeResourceForbidden:       {Code: ErrCodeResourceForbidden, Name: "ErrCodeResourceForbidden", Retryable: false, Explanation: "Our account is not allowed to use this endpoint. Ask XPO to enable it."},
+	ErrCodeSubscriptionInactive:    {Code: ErrCodeSubscriptionInactive, Name: "ErrCodeSubscriptionInactive", Retryable: false, Explanation: "Our subscription to this API is inactive. Ask XPO to reactivate it."},
+	ErrCodeScopeNotAllowed:         {Code: ErrCodeScopeNotAllowed, Name: "ErrCodeScopeNotAllowed", Retryable: false, Explanation: "The bearer token does not have the scope needed for this endpoint."},
+	ErrCodeBackendConnectFailed:    {Code: ErrCodeBackendConnectFailed, Name: "ErrCodeBackendConnectFailed", Retryable: true, Explanation: "XPO's gateway could not connect to the service behind it."},
+	ErrCodeBackendTimeout:          {Code: ErrCodeBackendTimeout, Name: "ErrCodeBackendTimeout", Retryable: true, Explanation: "XPO's gateway timed out waiting on the service behind it."},
+	ErrCodeBackendConnectionClosed: {Code: ErrCodeBackendConnectionClosed, Name: "ErrCodeBackendConnectionClosed", Retryable: true, Explanation: "The service behind XPO's gateway closed the connection."},
+	ErrCodeBackendEndpointTimeout:  {Code: ErrCodeBackendEndpointTimeout, Name: "ErrCodeBackendEndpointTimeout", Retryable: true, Explanation: "XPO's gateway timed out waiting on the service behind it."},
+	ErrCodeEndpointUnavailable:     {Code: ErrCodeEndpointUnavailable, Name: "ErrCodeEndpointUnavailable", Retryable: true, Explanation: "The service behind XPO's gateway is unavailable right now."},
+}
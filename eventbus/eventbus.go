@@ -0,0 +1,59 @@
+/*Package eventbus publishes xpo events to a message bus, like Kafka or NATS, so other systems can subscribe to
+pickup and tracking milestones.
+
+The adapters are written against small interfaces instead of a specific client library so this package does
+not pull a bus client into every program using xpo.  Each interface matches, or is a one line wrapper around,
+the common client for that bus.
+
+With NATS (github.com/nats-io/nats.go), *nats.Conn is already a NATSConn:
+
+	nc, err := nats.Connect(nats.DefaultURL)
+	client, err := xpo.NewClient(xpo.Config{..., EventPublisher: eventbus.NewNATS(nc, "xpo.events")})
+
+With Kafka (github.com/segmentio/kafka-go), wrap a *kafka.Writer:
+
+	w := &kafka.Writer{Addr: kafka.TCP("localhost:9092"), Topic: "xpo-events"}
+	producer := eventbus.KafkaProducerFunc(func(ctx context.Context, key, value []byte) error {
+		return w.WriteMessages(ctx, kafka.Message{Key: key, Value: value})
+	})
+	client, err := xpo.NewClient(xpo.Config{..., EventPublisher: eventbus.NewKafka(producer)})
+
+Events are encoded as json, see xpo.Event for the fields.
+*/
+package eventbus
+
+import (
+	"encoding/json"
+
+	"github.com/coreymgilmore/xpologistics"
+	"github.com/pkg/errors"
+)
+
+//encode returns the message body for an event
+func encode(e xpo.Event) (b []byte, err error) {
+	b, err = json.Marshal(e)
+	if err != nil {
+		err = errors.Wrap(err, "eventbus.encode - could not marshal event")
+		return
+	}
+
+	return
+}
+
+//key returns the key used to keep events for the same pickup or shipment together, like on one Kafka
+//partition, so they are received in order
+//Tracking events are keyed by PRO.  Pickup events are keyed by the stored pickup's id, which every event for
+//a pickup has when the client has a PickupStore, otherwise by XPO's pickup id, which only the booked event
+//has.  A pickup and the shipment it becomes can't be matched so their events may have different keys.
+func key(e xpo.Event) string {
+	switch {
+	case e.PRO != "":
+		return e.PRO
+	case e.RecordID != "":
+		return e.RecordID
+	case e.PickupID != "":
+		return e.PickupID
+	default:
+		return string(e.ConfirmationNbr)
+	}
+}
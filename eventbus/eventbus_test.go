@@ -0,0 +1,29 @@
+package eventbus
+
+import (
+	"testing"
+
+	"github.com/coreymgilmore/xpologistics"
+)
+
+func TestKeyPickupEvents(t *testing.T) {
+	//the events published for one stored pickup as it is booked
+	events := []xpo.Event{
+		{Type: xpo.EventStatusChanged, RecordID: "r1", Status: "queued", Error: "network error"},
+		{Type: xpo.EventPickupBooked, RecordID: "r1", ConfirmationNbr: "CHI123456", PickupID: "p1"},
+		{Type: xpo.EventStatusChanged, RecordID: "r1", ConfirmationNbr: "CHI123456", PickupID: "p1", Status: "confirmed"},
+	}
+
+	for _, e := range events {
+		if got := key(e); got != "r1" {
+			t.Errorf("got key %q for %s %s, want every event for the pickup keyed by its record", got, e.Type, e.Status)
+		}
+	}
+}
+
+func TestKeyTrackingEvents(t *testing.T) {
+	e := xpo.Event{Type: xpo.EventStatusChanged, PRO: "123-456789", Status: "IN_TRANSIT"}
+	if got := key(e); got != "123-456789" {
+		t.Errorf("got key %q, want the PRO", got)
+	}
+}
@@ -0,0 +1,53 @@
+package eventbus
+
+import (
+	"context"
+
+	"github.com/coreymgilmore/xpologistics"
+	"github.com/pkg/errors"
+)
+
+//KafkaProducer writes one message to a Kafka topic
+//The topic is chosen by the producer, usually when it is created.
+type KafkaProducer interface {
+	Produce(ctx context.Context, key, value []byte) error
+}
+
+//KafkaProducerFunc allows a plain func to be used as a KafkaProducer
+type KafkaProducerFunc func(ctx context.Context, key, value []byte) error
+
+//Produce calls f(ctx, key, value)
+func (f KafkaProducerFunc) Produce(ctx context.Context, key, value []byte) error {
+	return f(ctx, key, value)
+}
+
+//Kafka publishes events to Kafka
+//Messages are keyed so the tracking events for a shipment land on the same partition and are consumed in
+//order, and so do the events for a pickup when the client has a PickupStore.  Without a store a pickup only
+//has the one booked event.  A pickup's events and its shipment's events aren't keyed the same.
+type Kafka struct {
+	producer KafkaProducer
+}
+
+//NewKafka returns a publisher that writes events with p
+func NewKafka(p KafkaProducer) *Kafka {
+	return &Kafka{
+		producer: p,
+	}
+}
+
+//Publish writes an event to Kafka, this implements xpo.EventPublisher
+func (k *Kafka) Publish(ctx context.Context, e xpo.Event) (err error) {
+	b, err := encode(e)
+	if err != nil {
+		return
+	}
+
+	err = k.producer.Produce(ctx, []byte(key(e)), b)
+	if err != nil {
+		err = errors.Wrapf(err, "eventbus.Kafka.Publish - could not produce %s event", e.Type)
+		return
+	}
+
+	return
+}
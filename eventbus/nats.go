@@ -0,0 +1,55 @@
+package eventbus
+
+import (
+	"context"
+
+	"github.com/coreymgilmore/xpologistics"
+	"github.com/pkg/errors"
+)
+
+//NATSConn publishes a message to a NATS subject, *nats.Conn implements this
+type NATSConn interface {
+	Publish(subject string, data []byte) error
+}
+
+//NATS publishes events to NATS
+//Each event goes to the subject prefix followed by the event type, like "xpo.events.pickup.booked", so
+//subscribers can pick the events they want with wildcards.
+type NATS struct {
+	conn   NATSConn
+	prefix string
+}
+
+//NewNATS returns a publisher that sends events on conn under the subject prefix
+func NewNATS(conn NATSConn, prefix string) *NATS {
+	return &NATS{
+		conn:   conn,
+		prefix: prefix,
+	}
+}
+
+//Publish sends an event to NATS, this implements xpo.EventPublisher
+//NATS publishes are fire and forget so ctx is not used.
+func (n *NATS) Publish(ctx context.Context, e xpo.Event) (err error) {
+	b, err := encode(e)
+	if err != nil {
+		return
+	}
+
+	err = n.conn.Publish(n.subject(e), b)
+	if err != nil {
+		err = errors.Wrapf(err, "eventbus.NATS.Publish - could not publish %s event", e.Type)
+		return
+	}
+
+	return
+}
+
+//subject returns the subject an event is sent on
+func (n *NATS) subject(e xpo.Event) string {
+	if n.prefix == "" {
+		return string(e.Type)
+	}
+
+	return n.prefix + "." + string(e.Type)
+}
@@ -0,0 +1,70 @@
+package xpo
+
+import (
+	"context"
+	"time"
+)
+
+//EventType is what happened to cause an Event
+type EventType string
+
+//event types
+const (
+	EventPickupBooked  EventType = "pickup.booked"  //XPO returned a confirmation number for a pickup
+	EventStatusChanged EventType = "status.changed" //the status of a saved pickup or tracked shipment changed
+)
+
+//Event is sent to an EventPublisher when something happens with a pickup or shipment
+//Only the fields that make sense for the event type are set.  The json tags are the format used by the
+//adapters in the eventbus package.
+type Event struct {
+	Type   EventType `json:"type"`
+	Time   time.Time `json:"time"`
+	Tenant string    `json:"tenant,omitempty"`
+	Mode   Mode      `json:"mode"`
+
+	RecordID        string          `json:"recordId,omitempty"` //id of the pickup in the client's PickupStore, if it has one
+	ConfirmationNbr ConfirmationNbr `json:"confirmationNbr,omitempty"`
+	PickupID        string          `json:"pickupId,omitempty"` //XPO's pickup id
+	PRO             string          `json:"pro,omitempty"`      //tracking number of a shipment
+	Status          string          `json:"status,omitempty"`   //new status for EventStatusChanged
+	Error           string          `json:"error,omitempty"`    //why a pickup failed
+
+	//copied from the request so subscribers can match events to your own data, like order ids
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+//EventPublisher receives events from a client so downstream systems can subscribe to them instead of
+//polling a PickupStore
+//Publish is called synchronously so implementations should hand events off quickly, like to a message bus.
+//Errors are logged but do not fail the request that caused the event since XPO has already processed it.
+//Set this on Config.EventPublisher.
+type EventPublisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+//EventPublisherFunc allows a plain func to be used as an EventPublisher
+type EventPublisherFunc func(ctx context.Context, e Event) error
+
+//Publish calls f(ctx, e)
+func (f EventPublisherFunc) Publish(ctx context.Context, e Event) error {
+	return f(ctx, e)
+}
+
+//publish sends an event to the client's publisher, if one was given
+//The event is published even if ctx was canceled since whatever caused it has already happened.
+func (c *Client) publish(ctx context.Context, e Event) {
+	if c.events == nil {
+		return
+	}
+
+	e.Time = c.now()
+	e.Tenant = c.tenant
+	e.Mode = c.mode
+
+	err := c.events.Publish(context.WithoutCancel(ctx), e)
+	if err != nil {
+		c.logf("xpo.publish - could not publish %s event: %v", e.Type, err)
+	}
+	return
+}
@@ -0,0 +1,217 @@
+package xpo
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//ExportFormat is the file format written by Export
+type ExportFormat string
+
+//file formats for Export
+//ExportJSON writes one json object per line so large exports can be read a row at a time.
+const (
+	ExportCSV  ExportFormat = "csv"
+	ExportJSON ExportFormat = "json"
+)
+
+//ExportSource is where Export reads saved pickups from
+//SQLitePickupStore implements this.
+type ExportSource interface {
+	Pickups(ctx context.Context, from, to time.Time) ([]PickupRecord, error)
+	StatusChanges(ctx context.Context, id string) ([]PickupStatusChange, error)
+}
+
+//ExportRow is one pickup in an export
+//Fields are flat, plain values so the export loads into a spreadsheet or warehouse table without any
+//reshaping.  Times are UTC.
+type ExportRow struct {
+	RecordID        string    `json:"record_id"`
+	Mode            string    `json:"mode"`
+	Status          string    `json:"status"`
+	RequestedAt     time.Time `json:"requested_at"`
+	ConfirmedAt     time.Time `json:"confirmed_at"` //zero if the pickup was never confirmed
+	ConfirmationNbr string    `json:"confirmation_nbr"`
+	PickupID        string    `json:"pickup_id"`
+	PickupDate      string    `json:"pickup_date"` //YYYY-MM-DD
+	ShipperName     string    `json:"shipper_name"`
+	City            string    `json:"city"`
+	StateCd         string    `json:"state"`
+	PostalCd        string    `json:"postal_code"`
+	CountryCd       string    `json:"country"`
+	Pallets         uint      `json:"pallets"`
+	Pieces          uint      `json:"pieces"`
+	WeightLbs       uint      `json:"weight_lbs"`
+	Error           string    `json:"error"`
+	GLCode          string    `json:"gl_code"`
+	CostCenter      string    `json:"cost_center"`
+	Department      string    `json:"department"`
+}
+
+//exportCSVHeader is the header row of a csv export, in the same order as ExportRow
+var exportCSVHeader = []string{
+	"record_id",
+	"mode",
+	"status",
+	"requested_at",
+	"confirmed_at",
+	"confirmation_nbr",
+	"pickup_id",
+	"pickup_date",
+	"shipper_name",
+	"city",
+	"state",
+	"postal_code",
+	"country",
+	"pallets",
+	"pieces",
+	"weight_lbs",
+	"error",
+	"gl_code",
+	"cost_center",
+	"department",
+}
+
+//Export writes every pickup requested from (inclusive) to (exclusive) to w
+//Each pickup's status history is read to fill in when it was confirmed.  Tracking milestones are not included
+//since this package does not track shipments.
+func Export(ctx context.Context, src ExportSource, from, to time.Time, format ExportFormat, w io.Writer) (err error) {
+	records, err := src.Pickups(ctx, from, to)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.Export - could not get pickups")
+		return
+	}
+
+	var write func(ExportRow) error
+	var flush func() error
+	switch format {
+	case ExportCSV:
+		cw := csv.NewWriter(w)
+		err = cw.Write(exportCSVHeader)
+		if err != nil {
+			err = errors.Wrap(err, "xpo.Export - could not write header")
+			return
+		}
+
+		write = func(r ExportRow) error {
+			return cw.Write(r.csv())
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+
+	case ExportJSON:
+		e := json.NewEncoder(w)
+		write = func(r ExportRow) error {
+			return e.Encode(r)
+		}
+		flush = func() error {
+			return nil
+		}
+
+	default:
+		err = errors.New("xpo.Export - unknown format " + string(format))
+		return
+	}
+
+	for _, record := range records {
+		var changes []PickupStatusChange
+		changes, err = src.StatusChanges(ctx, record.ID)
+		if err != nil {
+			err = errors.Wrapf(err, "xpo.Export - could not get status changes for %s", record.ID)
+			return
+		}
+
+		err = write(newExportRow(record, changes))
+		if err != nil {
+			err = errors.Wrapf(err, "xpo.Export - could not write %s", record.ID)
+			return
+		}
+	}
+
+	err = flush()
+	if err != nil {
+		err = errors.Wrap(err, "xpo.Export - could not flush")
+		return
+	}
+
+	return
+}
+
+//newExportRow flattens a saved pickup and its status history
+func newExportRow(r PickupRecord, changes []PickupStatusChange) (row ExportRow) {
+	row = ExportRow{
+		RecordID:        r.ID,
+		Mode:            r.Mode.String(),
+		Status:          string(r.Status),
+		RequestedAt:     r.CreatedAt.UTC(),
+		ConfirmationNbr: string(r.ConfirmationNbr),
+		PickupID:        r.PickupID,
+		ShipperName:     r.Request.Shipper.Name,
+		City:            r.Request.Shipper.CityName,
+		StateCd:         r.Request.Shipper.StateCd,
+		PostalCd:        r.Request.Shipper.PostalCd,
+		CountryCd:       r.Request.Shipper.CountryCd,
+		Pallets:         r.Request.TotPalletCnt,
+		Pieces:          r.Request.TotLoosePieceCnt,
+		WeightLbs:       r.Request.TotWeight.Weight,
+		Error:           r.Error,
+		GLCode:          r.Accounting.GLCode,
+		CostCenter:      r.Accounting.CostCenter,
+		Department:      r.Accounting.Department,
+	}
+	if !r.Request.PkupDate.IsZero() {
+		row.PickupDate = r.Request.PkupDate.Format("2006-01-02")
+	}
+
+	for _, c := range changes {
+		if c.Status == PickupStatusConfirmed {
+			row.ConfirmedAt = c.Time.UTC()
+			break
+		}
+	}
+
+	return
+}
+
+//csv returns the row's fields in the same order as exportCSVHeader
+func (r ExportRow) csv() []string {
+	return []string{
+		r.RecordID,
+		r.Mode,
+		r.Status,
+		formatExportTime(r.RequestedAt),
+		formatExportTime(r.ConfirmedAt),
+		r.ConfirmationNbr,
+		r.PickupID,
+		r.PickupDate,
+		r.ShipperName,
+		r.City,
+		r.StateCd,
+		r.PostalCd,
+		r.CountryCd,
+		strconv.FormatUint(uint64(r.Pallets), 10),
+		strconv.FormatUint(uint64(r.Pieces), 10),
+		strconv.FormatUint(uint64(r.WeightLbs), 10),
+		r.Error,
+		r.GLCode,
+		r.CostCenter,
+		r.Department,
+	}
+}
+
+//formatExportTime formats a time for a csv export, zero times are left blank
+func formatExportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
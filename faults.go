@@ -0,0 +1,44 @@
+package xpo
+
+//go:generate go run gen_errcodes.go
+
+//FaultCode describes a known XPO fault code
+//Most of these come from XPO's API gateway rather than the endpoints themselves.  The table is generated
+//from errcodes.csv, add new codes there as they are seen.
+type FaultCode struct {
+	Code        string
+	Name        string //name of the ErrCode constant
+	Retryable   bool   //the same request may succeed if sent again later
+	Explanation string //human friendly explanation of the fault
+}
+
+//LookupFaultCode returns the details of a known fault code
+func LookupFaultCode(code string) (f FaultCode, ok bool) {
+	f, ok = faultCodes[code]
+	return
+}
+
+//APIError is returned when XPO rejects a request with a fault
+//Switch on Code with the ErrCode constants to handle specific faults:
+//
+//	var apiErr *xpo.APIError
+//	if errors.As(err, &apiErr) && apiErr.Code == xpo.ErrCodeInvalidCredentials {
+//		...
+//	}
+type APIError struct {
+	StatusCode  int
+	Code        string //XPO's fault code
+	Type        string
+	Message     string //cleaned up or translated message, see Config.Messages
+	Description string //XPO's description as sent
+}
+
+//Error returns the message
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+//FaultCode returns the details of the fault code, if it is a known one
+func (e *APIError) FaultCode() (FaultCode, bool) {
+	return LookupFaultCode(e.Code)
+}
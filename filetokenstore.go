@@ -0,0 +1,147 @@
+package xpo
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+//FileTokenStore is a TokenStore that saves tokens to a json file
+//This lets tokens survive restarts so a service doesn't get a new token every time it starts.  When a key is
+//given the file is encrypted with AES-GCM, otherwise it is plain json.  Either way the file is only readable
+//by the current user.
+type FileTokenStore struct {
+	path string
+	aead cipher.AEAD //nil when the file isn't encrypted
+
+	mu sync.Mutex
+}
+
+//NewFileTokenStore returns a token store saving to path
+//key is an AES key, 16, 24, or 32 bytes, used to encrypt the file.  Pass nil to save the file unencrypted.
+//Keep the key out of the same place as the file, like in a secrets manager, or encrypting it doesn't help.
+func NewFileTokenStore(path string, key []byte) (s *FileTokenStore, err error) {
+	s = &FileTokenStore{
+		path: path,
+	}
+
+	if key == nil {
+		return
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.NewFileTokenStore - invalid key")
+		return nil, err
+	}
+
+	s.aead, err = cipher.NewGCM(block)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.NewFileTokenStore - could not set up encryption")
+		return nil, err
+	}
+
+	return
+}
+
+//Token returns the token saved for key
+func (s *FileTokenStore) Token(ctx context.Context, key string) (t StoredToken, ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		err = errors.Wrap(err, "xpo.Token - could not read token file")
+		return
+	}
+
+	t, ok = tokens[key]
+	return
+}
+
+//SaveToken saves the token for key, replacing any token already saved
+func (s *FileTokenStore) SaveToken(ctx context.Context, key string, t StoredToken) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	//a file that can't be read, like after the key was changed, is replaced rather than failing forever
+	tokens, err := s.read()
+	if err != nil {
+		tokens = map[string]StoredToken{}
+	}
+	tokens[key] = t
+
+	err = s.write(tokens)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.SaveToken - could not write token file")
+		return
+	}
+
+	return
+}
+
+//read loads every token from the file, a missing file has no tokens
+func (s *FileTokenStore) read() (tokens map[string]StoredToken, err error) {
+	tokens = map[string]StoredToken{}
+
+	b, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return tokens, nil
+	}
+	if err != nil {
+		return
+	}
+
+	if s.aead != nil {
+		size := s.aead.NonceSize()
+		if len(b) < size {
+			err = errors.New("file is too short to be encrypted")
+			return
+		}
+
+		b, err = s.aead.Open(nil, b[:size], b[size:], nil)
+		if err != nil {
+			err = errors.Wrap(err, "could not decrypt, wrong key or file was changed")
+			return
+		}
+	}
+
+	err = json.Unmarshal(b, &tokens)
+	return
+}
+
+//write saves every token to a temporary file and renames it so a crash never leaves a partial file
+//Encrypted files are the random nonce followed by the sealed json.
+func (s *FileTokenStore) write(tokens map[string]StoredToken) (err error) {
+	b, err := json.Marshal(tokens)
+	if err != nil {
+		return
+	}
+
+	if s.aead != nil {
+		nonce := make([]byte, s.aead.NonceSize())
+		_, err = io.ReadFull(rand.Reader, nonce)
+		if err != nil {
+			return
+		}
+
+		b = s.aead.Seal(nonce, nonce, b, nil)
+	}
+
+	tmp := s.path + ".tmp"
+	err = ioutil.WriteFile(tmp, b, 0600)
+	if err != nil {
+		return
+	}
+
+	err = os.Rename(tmp, s.path)
+	return
+}
@@ -0,0 +1,61 @@
+//go:build ignore
+
+//gen_errcodes generates errcodes.go from errcodes.csv
+//Run with go generate after editing errcodes.csv.
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+)
+
+func main() {
+	f, err := os.Open("errcodes.csv")
+	if err != nil {
+		log.Fatalln("could not open errcodes.csv", err)
+	}
+	defer f.Close()
+
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		log.Fatalln("could not read errcodes.csv", err)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by gen_errcodes.go from errcodes.csv; DO NOT EDIT.\n\n")
+	b.WriteString("package xpo\n\n")
+
+	b.WriteString("//known XPO fault codes\n")
+	b.WriteString("const (\n")
+	for _, r := range rows[1:] {
+		fmt.Fprintf(&b, "%s = %q\n", r[1], r[0])
+	}
+	b.WriteString(")\n\n")
+
+	b.WriteString("//faultCodes holds the details of each known fault code\n")
+	b.WriteString("var faultCodes = map[string]FaultCode{\n")
+	for _, r := range rows[1:] {
+		retryable, err := strconv.ParseBool(r[2])
+		if err != nil {
+			log.Fatalln("invalid retryable value for", r[0], err)
+		}
+		fmt.Fprintf(&b, "%s: {Code: %s, Name: %q, Retryable: %t, Explanation: %q},\n", r[1], r[1], r[1], retryable, r[3])
+	}
+	b.WriteString("}\n")
+
+	out, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatalln("could not format generated code", err)
+	}
+
+	err = ioutil.WriteFile("errcodes.go", out, 0644)
+	if err != nil {
+		log.Fatalln("could not write errcodes.go", err)
+	}
+}
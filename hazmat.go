@@ -0,0 +1,195 @@
+package xpo
+
+import (
+	"regexp"
+	"strings"
+)
+
+//HazmatDetail describes one hazardous material on a pickup item
+//These are not sent to XPO, the pickup request only has a hazmat indicator, but they are checked against
+//known UN numbers so a misdeclared class is caught before the driver refuses the freight at the dock.
+type HazmatDetail struct {
+	UNNumber           string //UN or NA number, ex: UN1203
+	ProperShippingName string
+	HazardClass        string //primary class or division, ex: 3 or 2.1
+	PackingGroup       string //I, II, or III, blank for classes without packing groups like gases
+	Placard            string //optional, checked against the class when given, ex: FLAMMABLE
+}
+
+//HazmatEmergencyContact is the 24 hour emergency response phone for hazmat freight
+//Drivers refuse hazmat pickups without one.  A shipper can monitor its own number or contract with an emergency
+//response provider, in which case the provider's contract number has to be given too.
+type HazmatEmergencyContact struct {
+	Phone       Phone  //monitored 24 hours a day while the freight is in transit
+	Provider    string //emergency response provider like CHEMTREC, blank if the shipper monitors the phone itself
+	ContractNbr string //contract number with the provider
+}
+
+//String formats the emergency contact for the pickup remarks
+//ex: HAZMAT EMERGENCY 24HR 800-4249300 CHEMTREC CCN12345
+func (h HazmatEmergencyContact) String() string {
+	if h.Phone.PhoneNbr == "" {
+		return ""
+	}
+
+	parts := []string{"HAZMAT EMERGENCY 24HR", h.Phone.PhoneNbr}
+	if h.Provider != "" {
+		parts = append(parts, strings.TrimSpace(h.Provider))
+	}
+	if h.ContractNbr != "" {
+		parts = append(parts, strings.TrimSpace(h.ContractNbr))
+	}
+	return strings.Join(parts, " ")
+}
+
+//validate checks the emergency contact is complete, it is only required if the pickup has hazmat
+func (h HazmatEmergencyContact) validate(v *ValidationError) {
+	if strings.TrimSpace(h.Phone.PhoneNbr) == "" {
+		v.add("hazmat emergency phone is required when any item is hazmat")
+		return
+	}
+	if h.Phone.CountryCd != "" {
+		if err := h.Phone.Validate(); err != nil {
+			v.add("hazmat emergency phone: " + err.Error())
+		}
+	}
+	if strings.TrimSpace(h.Provider) != "" && strings.TrimSpace(h.ContractNbr) == "" {
+		v.add("hazmat emergency contract number is required when using provider " + h.Provider)
+	}
+	return
+}
+
+//HazmatReference is the reference data for a UN number
+type HazmatReference struct {
+	UNNumber           string
+	ProperShippingName string
+	HazardClasses      []string //classes the material can ship as, some like aerosols depend on the contents
+}
+
+//hazmatTable holds reference data for materials commonly shipped LTL
+//This is not the full hazardous materials table, UN numbers not listed here are only checked for format.
+var hazmatTable = map[string]HazmatReference{
+	"UN1005": {"UN1005", "Ammonia, anhydrous", []string{"2.2", "2.3"}},
+	"UN1017": {"UN1017", "Chlorine", []string{"2.3"}},
+	"UN1066": {"UN1066", "Nitrogen, compressed", []string{"2.2"}},
+	"UN1072": {"UN1072", "Oxygen, compressed", []string{"2.2"}},
+	"UN1075": {"UN1075", "Petroleum gases, liquefied", []string{"2.1"}},
+	"UN1090": {"UN1090", "Acetone", []string{"3"}},
+	"UN1133": {"UN1133", "Adhesives", []string{"3"}},
+	"UN1170": {"UN1170", "Ethanol", []string{"3"}},
+	"UN1202": {"UN1202", "Diesel fuel", []string{"3"}},
+	"UN1203": {"UN1203", "Gasoline", []string{"3"}},
+	"UN1219": {"UN1219", "Isopropanol", []string{"3"}},
+	"UN1263": {"UN1263", "Paint", []string{"3"}},
+	"UN1268": {"UN1268", "Petroleum distillates, n.o.s.", []string{"3"}},
+	"UN1325": {"UN1325", "Flammable solid, organic, n.o.s.", []string{"4.1"}},
+	"UN1428": {"UN1428", "Sodium", []string{"4.3"}},
+	"UN1760": {"UN1760", "Corrosive liquid, n.o.s.", []string{"8"}},
+	"UN1789": {"UN1789", "Hydrochloric acid", []string{"8"}},
+	"UN1823": {"UN1823", "Sodium hydroxide, solid", []string{"8"}},
+	"UN1824": {"UN1824", "Sodium hydroxide solution", []string{"8"}},
+	"UN1830": {"UN1830", "Sulfuric acid", []string{"8"}},
+	"UN1866": {"UN1866", "Resin solution", []string{"3"}},
+	"UN1942": {"UN1942", "Ammonium nitrate", []string{"5.1"}},
+	"UN1950": {"UN1950", "Aerosols", []string{"2.1", "2.2"}},
+	"UN1993": {"UN1993", "Flammable liquid, n.o.s.", []string{"3"}},
+	"UN2014": {"UN2014", "Hydrogen peroxide, aqueous solution", []string{"5.1"}},
+	"UN2031": {"UN2031", "Nitric acid", []string{"8"}},
+	"UN2794": {"UN2794", "Batteries, wet, filled with acid", []string{"8"}},
+	"UN2810": {"UN2810", "Toxic liquid, organic, n.o.s.", []string{"6.1"}},
+	"UN2811": {"UN2811", "Toxic solid, organic, n.o.s.", []string{"6.1"}},
+	"UN3065": {"UN3065", "Alcoholic beverages", []string{"3"}},
+	"UN3077": {"UN3077", "Environmentally hazardous substance, solid, n.o.s.", []string{"9"}},
+	"UN3082": {"UN3082", "Environmentally hazardous substance, liquid, n.o.s.", []string{"9"}},
+	"UN3266": {"UN3266", "Corrosive liquid, basic, inorganic, n.o.s.", []string{"8"}},
+	"UN3480": {"UN3480", "Lithium ion batteries", []string{"9"}},
+	"UN3481": {"UN3481", "Lithium ion batteries contained in equipment", []string{"9"}},
+}
+
+//hazmatPlacards is the placard required for each hazard class or division
+var hazmatPlacards = map[string]string{
+	"1.1": "EXPLOSIVES 1.1",
+	"1.2": "EXPLOSIVES 1.2",
+	"1.3": "EXPLOSIVES 1.3",
+	"1.4": "EXPLOSIVES 1.4",
+	"1.5": "EXPLOSIVES 1.5",
+	"1.6": "EXPLOSIVES 1.6",
+	"2.1": "FLAMMABLE GAS",
+	"2.2": "NON-FLAMMABLE GAS",
+	"2.3": "POISON GAS",
+	"3":   "FLAMMABLE",
+	"4.1": "FLAMMABLE SOLID",
+	"4.2": "SPONTANEOUSLY COMBUSTIBLE",
+	"4.3": "DANGEROUS WHEN WET",
+	"5.1": "OXIDIZER",
+	"5.2": "ORGANIC PEROXIDE",
+	"6.1": "POISON",
+	"7":   "RADIOACTIVE",
+	"8":   "CORROSIVE",
+	"9":   "CLASS 9",
+}
+
+//unNumberRegex matches UN and NA numbers
+var unNumberRegex = regexp.MustCompile(`^(UN|NA)[0-9]{4}$`)
+
+//LookupUNNumber returns the reference data for a UN number, if it is in this package's table
+func LookupUNNumber(unNumber string) (r HazmatReference, ok bool) {
+	r, ok = hazmatTable[normalizeUNNumber(unNumber)]
+	return
+}
+
+//PlacardForClass returns the placard required for a hazard class or division
+func PlacardForClass(class string) (placard string, ok bool) {
+	placard, ok = hazmatPlacards[strings.TrimSpace(class)]
+	return
+}
+
+//normalizeUNNumber uppercases a UN number and removes spaces, "un 1203" becomes "UN1203"
+func normalizeUNNumber(s string) string {
+	return strings.ToUpper(strings.Replace(strings.TrimSpace(s), " ", "", -1))
+}
+
+//validate checks a hazmat detail against the reference tables
+//prefix is added to each problem so it is known which item the problem is with.
+func (h HazmatDetail) validate(prefix string, v *ValidationError) {
+	un := normalizeUNNumber(h.UNNumber)
+	switch {
+	case un == "":
+		v.add(prefix + "UN number is required")
+	case !unNumberRegex.MatchString(un):
+		v.add(prefix + "UN number " + h.UNNumber + " must be UN or NA followed by 4 digits")
+	}
+
+	class := strings.TrimSpace(h.HazardClass)
+	placard, ok := PlacardForClass(class)
+	if !ok {
+		v.add(prefix + "hazard class " + h.HazardClass + " is not valid")
+		return
+	}
+
+	if ref, known := LookupUNNumber(un); known && !containsString(ref.HazardClasses, class) {
+		v.add(prefix + un + " (" + ref.ProperShippingName + ") is class " + strings.Join(ref.HazardClasses, " or ") + ", not " + class)
+	}
+
+	if h.Placard != "" && !strings.EqualFold(strings.TrimSpace(h.Placard), placard) {
+		v.add(prefix + "class " + class + " requires placard " + placard + ", not " + h.Placard)
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(h.PackingGroup)) {
+	case "", "I", "II", "III":
+	default:
+		v.add(prefix + "packing group " + h.PackingGroup + " must be I, II, or III")
+	}
+	return
+}
+
+//containsString checks if s is in list
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
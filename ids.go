@@ -0,0 +1,120 @@
+package xpo
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//PRO is an XPO PRO number, the number freight is tracked by
+//PROs are 9 digits, usually written with a hyphen after the third digit (123-456789).  XPO also uses an 11
+//digit form with a zero added in front of each part (01230456789).  ParsePRO accepts either and normalizes
+//to the hyphenated 9 digit form.
+type PRO string
+
+//ConfirmationNbr is a pickup request confirmation number
+type ConfirmationNbr string
+
+//QuoteID is a rate quote number
+type QuoteID string
+
+//identifier formats
+var (
+	pro9Regex        = regexp.MustCompile(`^[0-9]{3}-?[0-9]{6}$`)
+	pro11Regex       = regexp.MustCompile(`^0[0-9]{3}0[0-9]{6}$`)
+	referenceIDRegex = regexp.MustCompile(`^[A-Z0-9][A-Z0-9-]*$`)
+)
+
+//ParsePRO validates and normalizes a PRO number
+func ParsePRO(s string) (p PRO, err error) {
+	s = strings.Replace(strings.TrimSpace(s), " ", "", -1)
+
+	switch {
+	case pro11Regex.MatchString(s):
+		s = s[1:4] + s[5:]
+	case pro9Regex.MatchString(s):
+		s = strings.Replace(s, "-", "", 1)
+	default:
+		err = errors.New("xpo.ParsePRO - " + s + " is not a 9 or 11 digit PRO number")
+		return
+	}
+
+	p = PRO(s[:3] + "-" + s[3:])
+	return
+}
+
+//Valid checks if the PRO is a normalized 9 digit PRO number
+func (p PRO) Valid() bool {
+	return pro9Regex.MatchString(string(p)) && strings.Contains(string(p), "-")
+}
+
+//Digits11 returns the PRO in XPO's 11 digit format
+func (p PRO) Digits11() string {
+	s := strings.Replace(string(p), "-", "", 1)
+	if len(s) != 9 {
+		return string(p)
+	}
+
+	return "0" + s[:3] + "0" + s[3:]
+}
+
+//String returns the PRO number
+func (p PRO) String() string {
+	return string(p)
+}
+
+//ParseConfirmationNbr validates and normalizes a pickup confirmation number
+//Confirmation numbers are uppercased with whitespace removed.
+func ParseConfirmationNbr(s string) (c ConfirmationNbr, err error) {
+	s, err = parseReferenceID(s)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.ParseConfirmationNbr")
+		return
+	}
+
+	c = ConfirmationNbr(s)
+	return
+}
+
+//Valid checks that the confirmation number is not empty and has no unexpected characters
+func (c ConfirmationNbr) Valid() bool {
+	return referenceIDRegex.MatchString(string(c))
+}
+
+//String returns the confirmation number
+func (c ConfirmationNbr) String() string {
+	return string(c)
+}
+
+//ParseQuoteID validates and normalizes a quote number
+func ParseQuoteID(s string) (q QuoteID, err error) {
+	s, err = parseReferenceID(s)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.ParseQuoteID")
+		return
+	}
+
+	q = QuoteID(s)
+	return
+}
+
+//Valid checks that the quote id is not empty and has no unexpected characters
+func (q QuoteID) Valid() bool {
+	return referenceIDRegex.MatchString(string(q))
+}
+
+//String returns the quote id
+func (q QuoteID) String() string {
+	return string(q)
+}
+
+//parseReferenceID cleans up a confirmation number or quote id
+func parseReferenceID(s string) (string, error) {
+	s = strings.ToUpper(strings.Join(strings.Fields(s), ""))
+	if !referenceIDRegex.MatchString(s) {
+		return "", errors.New(s + " is not a valid reference number")
+	}
+
+	return s, nil
+}
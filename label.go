@@ -0,0 +1,138 @@
+package xpo
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//PalletLabel is the data printed on a handling unit label
+//Labels are 4x6 inches for 203 dpi thermal printers, the size Zebra printers in most warehouses use.
+type PalletLabel struct {
+	Shipper   LabelAddress
+	Consignee LabelAddress
+	PRO       PRO
+
+	//handling units in the shipment, a label is printed for each one
+	HandlingUnits uint
+	Weight        uint //lbs, total for the shipment, optional
+	Hazmat        bool
+	DoNotStack    bool
+}
+
+//LabelAddress is a name and address printed on a label
+type LabelAddress struct {
+	Name         string
+	AddressLine1 string
+	AddressLine2 string
+	CityName     string
+	StateCd      string
+	PostalCd     string
+}
+
+//ShipperLabelAddress returns the label address for a pickup's shipper
+func ShipperLabelAddress(s Shipper) LabelAddress {
+	return LabelAddress{
+		Name:         s.Name,
+		AddressLine1: s.AddressLine1,
+		AddressLine2: s.AddressLine2,
+		CityName:     s.CityName,
+		StateCd:      s.StateCd,
+		PostalCd:     s.PostalCd,
+	}
+}
+
+//lines returns the address as the lines printed on a label
+func (a LabelAddress) lines() (l []string) {
+	for _, s := range []string{a.Name, a.AddressLine1, a.AddressLine2} {
+		if s = strings.TrimSpace(s); s != "" {
+			l = append(l, s)
+		}
+	}
+
+	city := strings.TrimSpace(a.CityName + ", " + a.StateCd + " " + a.PostalCd)
+	if city != "," {
+		l = append(l, city)
+	}
+	return
+}
+
+//WriteZPL writes one ZPL label per handling unit to w, numbered 1 of n, 2 of n, etc.
+//The PRO is printed as a Code 128 barcode of the 11 digit PRO that XPO's scanners read.
+func (l PalletLabel) WriteZPL(w io.Writer) (err error) {
+	if !l.PRO.Valid() {
+		err = errors.New("xpo.WriteZPL - invalid PRO " + string(l.PRO))
+		return
+	}
+	if l.HandlingUnits == 0 {
+		err = errors.New("xpo.WriteZPL - handling units must be at least 1")
+		return
+	}
+
+	for i := uint(1); i <= l.HandlingUnits; i++ {
+		_, err = io.WriteString(w, l.zpl(i))
+		if err != nil {
+			err = errors.Wrap(err, "xpo.WriteZPL - could not write label")
+			return
+		}
+	}
+
+	return
+}
+
+//zpl returns the ZPL for handling unit n
+//Positions are in dots, 812x1218 for a 4x6 label at 203 dpi.
+func (l PalletLabel) zpl(n uint) string {
+	var b strings.Builder
+	b.WriteString("^XA\n^CI28\n^PW812\n^LL1218\n")
+
+	//ship from, small since the driver mostly cares where it is going
+	zplText(&b, 30, 30, 28, "FROM:")
+	y := 65
+	for _, s := range l.Shipper.lines() {
+		zplText(&b, 30, y, 28, s)
+		y += 34
+	}
+	b.WriteString("^FO0,215^GB812,3,3^FS\n")
+
+	//ship to
+	zplText(&b, 30, 240, 32, "TO:")
+	y = 285
+	for _, s := range l.Consignee.lines() {
+		zplText(&b, 30, y, 48, s)
+		y += 56
+	}
+	b.WriteString("^FO0,530^GB812,3,3^FS\n")
+
+	//PRO barcode
+	zplText(&b, 30, 555, 32, "XPO PRO: "+string(l.PRO))
+	fmt.Fprintf(&b, "^FO60,600^BY3^BCN,220,Y,N,N^FD%s^FS\n", l.PRO.Digits11())
+	b.WriteString("^FO0,900^GB812,3,3^FS\n")
+
+	//handling unit count
+	zplText(&b, 30, 930, 80, "PIECE "+strconv.FormatUint(uint64(n), 10)+" OF "+strconv.FormatUint(uint64(l.HandlingUnits), 10))
+	if l.Weight > 0 {
+		zplText(&b, 30, 1040, 40, "TOTAL WEIGHT: "+strconv.FormatUint(uint64(l.Weight), 10)+" LBS")
+	}
+	if l.Hazmat {
+		b.WriteString("^FO560,1030^GB220,150,150^FS\n")
+		b.WriteString("^FO560,1080^FR^A0N,50,50^FB220,1,0,C^FDHAZMAT^FS\n")
+	}
+	if l.DoNotStack {
+		b.WriteString("^FO30,1100^GB500,90,6^FS\n")
+		b.WriteString("^FO30,1120^A0N,56,56^FB500,1,0,C^FDDO NOT STACK^FS\n")
+	}
+
+	b.WriteString("^XZ\n")
+	return b.String()
+}
+
+//zplText writes a line of text at x,y with the given font height
+//Text is hex escaped with ^FH so ^ and ~ in names and addresses can't be read as ZPL commands.
+func zplText(b *strings.Builder, x, y, height int, s string) {
+	s = strings.NewReplacer("_", "_5F", "^", "_5E", "~", "_7E").Replace(s)
+	fmt.Fprintf(b, "^FO%d,%d^A0N,%d,%d^FH_^FD%s^FS\n", x, y, height, height, s)
+}
@@ -0,0 +1,131 @@
+package xpo
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/coreymgilmore/xpologistics/transport"
+)
+
+//latencyWindow is how many of the most recent requests are kept per endpoint for calculating stats
+const latencyWindow = 200
+
+//endpoint names used for latency stats
+const (
+	EndpointToken    = transport.EndpointToken
+	EndpointPickup   = "pickup"
+	EndpointTracking = "tracking"
+)
+
+//LatencyStats holds rolling latency info for one XPO endpoint
+//Stats are calculated off of the most recent requests only so they show how XPO is performing right now.
+type LatencyStats struct {
+	Tenant    string
+	Endpoint  string
+	Count     int           //number of requests the stats were calculated from
+	P50       time.Duration //median
+	P95       time.Duration
+	ErrorRate float64 //0 to 1
+}
+
+//latencySample is the result of one request
+type latencySample struct {
+	duration time.Duration
+	failed   bool
+}
+
+//latencyRecorder keeps the most recent samples for each endpoint
+//The zero value is ready to use.
+type latencyRecorder struct {
+	mu        sync.Mutex
+	endpoints map[string]*latencyRing
+}
+
+//latencyRing is a fixed size ring buffer of samples
+type latencyRing struct {
+	samples []latencySample
+	next    int
+}
+
+//record saves the result of a request
+func (l *latencyRecorder) record(endpoint string, d time.Duration, failed bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.endpoints == nil {
+		l.endpoints = map[string]*latencyRing{}
+	}
+
+	r, ok := l.endpoints[endpoint]
+	if !ok {
+		r = &latencyRing{}
+		l.endpoints[endpoint] = r
+	}
+
+	s := latencySample{
+		duration: d,
+		failed:   failed,
+	}
+	if len(r.samples) < latencyWindow {
+		r.samples = append(r.samples, s)
+		return
+	}
+
+	r.samples[r.next] = s
+	r.next = (r.next + 1) % latencyWindow
+	return
+}
+
+//stats calculates the stats for every endpoint, sorted by endpoint name
+func (l *latencyRecorder) stats() (s []LatencyStats) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s = make([]LatencyStats, 0, len(l.endpoints))
+	for name, r := range l.endpoints {
+		durations := make([]time.Duration, 0, len(r.samples))
+		var failed int
+		for _, v := range r.samples {
+			durations = append(durations, v.duration)
+			if v.failed {
+				failed++
+			}
+		}
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+		s = append(s, LatencyStats{
+			Endpoint:  name,
+			Count:     len(durations),
+			P50:       percentile(durations, 50),
+			P95:       percentile(durations, 95),
+			ErrorRate: float64(failed) / float64(len(durations)),
+		})
+	}
+
+	sort.Slice(s, func(i, j int) bool { return s[i].Endpoint < s[j].Endpoint })
+	return
+}
+
+//percentile returns the p-th percentile of sorted durations using the nearest rank method
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+//LatencyStats returns rolling latency stats for each endpoint this client has made requests to
+//Use this to show when XPO is slow or erroring without needing any external instrumentation.
+func (c *Client) LatencyStats() (s []LatencyStats) {
+	s = c.latency.stats()
+	for i := range s {
+		s[i].Tenant = c.tenant
+	}
+	return
+}
@@ -0,0 +1,99 @@
+package xpo
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//the package level functions use a default client built from the settings given to SetCredentials(),
+//SetMode(), and SetTimeout()
+//The client is created on first use and recreated whenever a setting changes.  SetMode(ModeProduction) is
+//the explicit opt in to production for the package level functions, so production is always allowed.
+//
+//This is not source compatible with the old API.  PkupDate, ReadyTime, CloseTime, TransactionTimestamp, and
+//ConfirmationNbr are no longer plain strings and numbers so code setting or reading them has to be changed, see
+//ParseDate, ParseTime, and the README.  Pickups aren't validated here, as before, but the request sent is
+//built the new way, with extra contacts and notes folded into the remarks.
+var (
+	defaultMu     sync.Mutex
+	defaultConfig = Config{
+		Mode:            ModeTest,
+		AllowProduction: true,
+	}
+	defaultClient *Client
+)
+
+//getDefaultClient returns the default client, creating it if needed
+func getDefaultClient() *Client {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultClient == nil {
+		defaultClient = newClient(defaultConfig)
+	}
+	return defaultClient
+}
+
+//updateDefaultConfig changes a setting and drops the default client so the next request uses the new setting
+func updateDefaultConfig(f func(cfg *Config)) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	f(&defaultConfig)
+	defaultClient = nil
+	return
+}
+
+//SetMode chooses the mode used by the package level functions
+//
+//Deprecated: create a Client with NewClient() and set Config.Mode instead.
+func SetMode(m Mode) {
+	updateDefaultConfig(func(cfg *Config) {
+		cfg.Mode = m
+	})
+	return
+}
+
+//SetProductionMode chooses the production url for use
+//
+//Deprecated: create a Client with NewClient() and set Config.Mode instead.
+func SetProductionMode(yes bool) {
+	if yes {
+		SetMode(ModeProduction)
+	}
+	return
+}
+
+//SetTimeout updates the timeout value to something the user sets
+//use this to increase the timeout if connecting to XPO is really slow
+//
+//Deprecated: create a Client with NewClient() and set Config.Timeout instead.
+func SetTimeout(seconds time.Duration) {
+	updateDefaultConfig(func(cfg *Config) {
+		cfg.Timeout = time.Duration(seconds * time.Second)
+	})
+	return
+}
+
+//SetCredentials saves our XPO username, password, access token for use later.
+//
+//Deprecated: create a Client with NewClient() and set the credentials on Config instead.
+func SetCredentials(u, p, t string) {
+	updateDefaultConfig(func(cfg *Config) {
+		cfg.Username = u
+		cfg.Password = p
+		cfg.AccessToken = t
+	})
+	return
+}
+
+//RequestPickup performs the API call to schedule a pickup using the default client
+//The pickup is totaled and sent without being validated, like this always did.  Client.RequestPickup
+//validates first and will reject some pickups this sends.
+//
+//Deprecated: create a Client with NewClient() and use Client.RequestPickup() instead.
+func (pri *PickupRqstInfo) RequestPickup() (response SuccessfulPickupResponse, err error) {
+	response, _, err = getDefaultClient().requestPickup(context.Background(), pri, pickupAttempt{unvalidated: true})
+	return
+}
@@ -0,0 +1,174 @@
+package xpo
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/coreymgilmore/xpologistics/transport"
+	"github.com/pkg/errors"
+)
+
+//ErrMaintenance is returned, wrapped in a *MaintenanceError, when XPO is down for maintenance
+//Check for it with errors.Is and back off until MaintenanceError.Until instead of retrying.
+var ErrMaintenance = errors.New("xpo - XPO is down for maintenance")
+
+//MaintenanceError is returned when XPO responds with its maintenance page
+type MaintenanceError struct {
+	StatusCode int
+	Until      time.Time //when XPO said to try again, zero if it didn't say
+	Message    string    //the text of XPO's maintenance message, shortened
+}
+
+//Error includes when XPO said it will be back, if known
+func (m *MaintenanceError) Error() string {
+	s := ErrMaintenance.Error()
+	if !m.Until.IsZero() {
+		s += " until " + m.Until.Format(time.RFC3339)
+	}
+	if m.Message != "" {
+		s += ": " + m.Message
+	}
+	return s
+}
+
+//Is makes errors.Is(err, ErrMaintenance) true
+func (m *MaintenanceError) Is(target error) bool {
+	return target == ErrMaintenance
+}
+
+//maintenanceMessageLength is how much of XPO's maintenance message is kept
+const maintenanceMessageLength = 200
+
+//htmlTagRegex matches html tags, and scripts and styles with their contents, to get the text of a page
+var htmlTagRegex = regexp.MustCompile(`(?s)<(script|style)[^>]*>.*?</(script|style)>|<[^>]+>`)
+
+//maintenanceUntilRegex finds when XPO says it will be back in a maintenance message
+//ex: "back online at 6:00 AM CST", "from 10 PM to 2 AM ET", "until 10/18/2026 06:00 CT"
+var maintenanceUntilRegex = regexp.MustCompile(`(?i)\b(?:until|till|through|thru|to|back(?:\s+(?:online|up))?(?:\s+(?:at|by))?|ends?(?:\s+at)?)\s+` +
+	`((?:\d{1,2}/\d{1,2}/\d{4}|\d{4}-\d{2}-\d{2})?[\sT]*\d{1,2}(?::\d{2})?\s*[ap]\.?m\.?(?:\s+[A-Z]{2,4}\b)?|` +
+	`(?:\d{1,2}/\d{1,2}/\d{4}|\d{4}-\d{2}-\d{2})?[\sT]*\d{1,2}:\d{2}(?:\s+[A-Z]{2,4}\b)?)`)
+
+//maintenanceZones are the time zone abbreviations XPO uses in maintenance messages, as UTC offsets in hours
+//The generic ones, like ET, are taken as standard time.
+var maintenanceZones = map[string]int{
+	"UTC": 0, "GMT": 0, "Z": 0,
+	"ET": -5, "EST": -5, "EDT": -4,
+	"CT": -6, "CST": -6, "CDT": -5,
+	"MT": -7, "MST": -7, "MDT": -6,
+	"PT": -8, "PST": -8, "PDT": -7,
+}
+
+//maintenance checks if a response is XPO's maintenance page, see transport.IsMaintenance
+//Until is taken from the Retry-After header or, if there isn't one, from the window given in the message.
+func maintenance(res transport.Response, now time.Time) (m *MaintenanceError, ok bool) {
+	if !transport.IsMaintenance(res.StatusCode, res.Body) {
+		return nil, false
+	}
+
+	msg := cleanMessage(htmlTagRegex.ReplaceAllString(string(res.Body), " "))
+
+	until := retryAfter(res.Header, now)
+	if until.IsZero() {
+		until = advertisedUntil(msg, now)
+	}
+
+	m = &MaintenanceError{
+		StatusCode: res.StatusCode,
+		Until:      until,
+		Message:    truncateMessage(msg, maintenanceMessageLength),
+	}
+	return m, true
+}
+
+//truncateMessage shortens s to at most n bytes, cutting at the start of a character so it stays valid utf-8
+func truncateMessage(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
+
+//retryAfter returns the time given in a Retry-After header, as seconds or an http date, zero if there isn't one
+func retryAfter(h http.Header, now time.Time) time.Time {
+	v := strings.TrimSpace(h.Get("Retry-After"))
+	if v == "" {
+		return time.Time{}
+	}
+
+	if s, err := strconv.Atoi(v); err == nil && s > 0 {
+		return now.Add(time.Duration(s) * time.Second)
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return t
+	}
+
+	return time.Time{}
+}
+
+//advertisedUntil returns when a maintenance message says XPO will be back, zero if it doesn't say
+//Times without a date are the next time that clock time comes around after now.  Times without a time zone
+//are in now's location.
+func advertisedUntil(msg string, now time.Time) time.Time {
+	match := maintenanceUntilRegex.FindStringSubmatch(msg)
+	if match == nil {
+		return time.Time{}
+	}
+
+	//split a date from its time, drop the dots from a.m. and p.m.
+	v := strings.Replace(strings.ToUpper(match[1]), ".", "", -1)
+	if i := strings.Index(v, "T"); i > 0 && v[i-1] >= '0' && v[i-1] <= '9' {
+		v = v[:i] + " " + v[i+1:]
+	}
+	fields := strings.Fields(v)
+
+	loc := now.Location()
+	if offset, ok := maintenanceZones[fields[len(fields)-1]]; ok {
+		loc = time.FixedZone(fields[len(fields)-1], offset*60*60)
+		fields = fields[:len(fields)-1]
+	}
+	now = now.In(loc)
+
+	var day time.Time
+	if len(fields) > 0 {
+		for _, layout := range []string{"1/2/2006", "2006-01-02"} {
+			if d, err := time.ParseInLocation(layout, fields[0], loc); err == nil {
+				day = d
+				fields = fields[1:]
+				break
+			}
+		}
+	}
+
+	clock := strings.Join(fields, "")
+	var t time.Time
+	var err error
+	for _, layout := range []string{"3:04PM", "3PM", "15:04"} {
+		t, err = time.Parse(layout, clock)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return time.Time{}
+	}
+
+	if day.IsZero() {
+		y, m, d := now.Date()
+		until := time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, loc)
+		if !until.After(now) {
+			until = until.AddDate(0, 0, 1)
+		}
+		return until
+	}
+
+	y, m, d := day.Date()
+	return time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, loc)
+}
@@ -0,0 +1,96 @@
+package xpo
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/coreymgilmore/xpologistics/transport"
+)
+
+func TestAdvertisedUntil(t *testing.T) {
+	cdt := time.FixedZone("CDT", -5*60*60)
+	now := time.Date(2026, 10, 16, 23, 0, 0, 0, cdt)
+
+	tests := []struct {
+		msg  string
+		want time.Time
+	}{
+		{"XPO is down for scheduled maintenance. We will be back online at 6:00 AM CST.", time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)},
+		{"Maintenance from 10 PM to 2 a.m. ET", time.Date(2026, 10, 17, 7, 0, 0, 0, time.UTC)},
+		{"maintenance until 10/18/2026 06:00 CT", time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)},
+		{"maintenance until 2026-10-18T06:00 UTC", time.Date(2026, 10, 18, 6, 0, 0, 0, time.UTC)},
+		{"down for maintenance until 23:30", time.Date(2026, 10, 16, 23, 30, 0, 0, cdt)},
+		{"down for maintenance until 22:30", time.Date(2026, 10, 17, 22, 30, 0, 0, cdt)},
+		{"maintenance, try again in 5 minutes", time.Time{}},
+		{"Please try again later.", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		if got := advertisedUntil(tt.msg, now); !got.Equal(tt.want) {
+			t.Errorf("advertisedUntil(%q) = %v, want %v", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestMaintenance(t *testing.T) {
+	now := time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC)
+	page := `<html><body><h1>Scheduled Maintenance</h1><p>` + strings.Repeat("é", 150) + ` back online at 2:00 AM UTC</p></body></html>`
+
+	m, ok := maintenance(transport.Response{StatusCode: http.StatusServiceUnavailable, Body: []byte(page)}, now)
+	if !ok {
+		t.Fatal("maintenance page not detected")
+	}
+	if want := time.Date(2026, 10, 17, 2, 0, 0, 0, time.UTC); !m.Until.Equal(want) {
+		t.Errorf("got until %v, want %v from the page", m.Until, want)
+	}
+	if !utf8.ValidString(m.Message) || len(m.Message) > maintenanceMessageLength+len("...") {
+		t.Errorf("got message %q, want valid utf-8 no longer than %d bytes", m.Message, maintenanceMessageLength)
+	}
+
+	header := http.Header{"Retry-After": []string{"600"}}
+	m, _ = maintenance(transport.Response{StatusCode: http.StatusServiceUnavailable, Header: header, Body: []byte(page)}, now)
+	if want := now.Add(10 * time.Minute); !m.Until.Equal(want) {
+		t.Errorf("got until %v, want %v from Retry-After", m.Until, want)
+	}
+
+	if _, ok := maintenance(transport.Response{StatusCode: http.StatusOK, Body: []byte(`{"remarks":"maintenance dept"}`)}, now); ok {
+		t.Error("successful response mistaken for maintenance")
+	}
+}
+
+func TestMaintenanceNotFault(t *testing.T) {
+	now := time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+	}{
+		{
+			name:       "xml fault",
+			statusCode: http.StatusServiceUnavailable,
+			body:       `<am:fault xmlns:am="http://wso2.org/apimanager"><am:code>101503</am:code><am:message>Runtime Error</am:message><am:description>Backend unavailable, the pickup service is in maintenance mode</am:description></am:fault>`,
+		},
+		{
+			name:       "json error",
+			statusCode: http.StatusServiceUnavailable,
+			body:       `{"code":"503","error":{"message":"dock maintenance scheduled"}}`,
+		},
+		{
+			name:       "client error page",
+			statusCode: http.StatusBadRequest,
+			body:       `<html><body>maintenance</body></html>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := maintenance(transport.Response{StatusCode: tt.statusCode, Body: []byte(tt.body)}, now); ok {
+				t.Errorf("got maintenance for %s", tt.body)
+			}
+		})
+	}
+}
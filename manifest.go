@@ -0,0 +1,215 @@
+package xpo
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//Manifest is the list of pickups booked for one day, for the dock office
+type Manifest struct {
+	Date    time.Time
+	Pickups []ManifestPickup
+
+	//totals for all pickups
+	TotalPallets uint
+	TotalPieces  uint
+	TotalWeight  uint //lbs
+}
+
+//ManifestPickup is one pickup on a manifest
+type ManifestPickup struct {
+	ConfirmationNbr ConfirmationNbr
+	PickupID        string
+	Mode            Mode
+
+	ShipperName string
+	Address     string
+	City        string
+	StateCd     string
+	PostalCd    string
+
+	ReadyTime time.Time
+	CloseTime time.Time
+
+	Pallets uint
+	Pieces  uint
+	Weight  uint //lbs
+	Hazmat  bool
+	Remarks string
+}
+
+//BuildManifest assembles the manifest for date from saved pickup records
+//Only confirmed pickups with a pickup date on date are included, no matter when they were requested, so
+//pass every record that could have been booked for that day (see SQLitePickupStore.Pickups).  Pickups are
+//sorted by ready time.
+func BuildManifest(date time.Time, records []PickupRecord) (m Manifest) {
+	y, mo, d := date.Date()
+	m.Date = time.Date(y, mo, d, 0, 0, 0, 0, date.Location())
+
+	for _, r := range records {
+		if r.Status != PickupStatusConfirmed {
+			continue
+		}
+
+		py, pmo, pd := r.Request.PkupDate.Date()
+		if py != y || pmo != mo || pd != d {
+			continue
+		}
+
+		p := ManifestPickup{
+			ConfirmationNbr: r.ConfirmationNbr,
+			PickupID:        r.PickupID,
+			Mode:            r.Mode,
+			ShipperName:     r.Request.Shipper.Name,
+			Address:         strings.TrimSpace(r.Request.Shipper.AddressLine1 + " " + r.Request.Shipper.AddressLine2),
+			City:            r.Request.Shipper.CityName,
+			StateCd:         r.Request.Shipper.StateCd,
+			PostalCd:        r.Request.Shipper.PostalCd,
+			ReadyTime:       r.Request.ReadyTime.Time,
+			CloseTime:       r.Request.CloseTime.Time,
+			Pallets:         r.Request.TotPalletCnt,
+			Pieces:          r.Request.TotLoosePieceCnt,
+			Weight:          r.Request.TotWeight.Weight,
+			Remarks:         r.Request.Remarks,
+		}
+		for _, item := range r.Request.PkupItem {
+			p.Hazmat = p.Hazmat || item.HazmatInd
+		}
+
+		m.Pickups = append(m.Pickups, p)
+		m.TotalPallets += p.Pallets
+		m.TotalPieces += p.Pieces
+		m.TotalWeight += p.Weight
+	}
+
+	sort.SliceStable(m.Pickups, func(i, j int) bool {
+		return m.Pickups[i].ReadyTime.Before(m.Pickups[j].ReadyTime)
+	})
+
+	return
+}
+
+//manifestCSVHeader is the header row of a csv manifest
+var manifestCSVHeader = []string{
+	"confirmation_nbr",
+	"pickup_id",
+	"mode",
+	"shipper_name",
+	"address",
+	"city",
+	"state",
+	"postal_code",
+	"ready_time",
+	"close_time",
+	"pallets",
+	"pieces",
+	"weight_lbs",
+	"hazmat",
+	"remarks",
+}
+
+//WriteCSV writes the manifest as csv with a header row, one row per pickup
+//Times are RFC3339 so they can be imported without guessing the time zone.
+func (m Manifest) WriteCSV(w io.Writer) (err error) {
+	cw := csv.NewWriter(w)
+
+	err = cw.Write(manifestCSVHeader)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.WriteCSV - could not write header")
+		return
+	}
+
+	for _, p := range m.Pickups {
+		err = cw.Write([]string{
+			string(p.ConfirmationNbr),
+			p.PickupID,
+			p.Mode.String(),
+			p.ShipperName,
+			p.Address,
+			p.City,
+			p.StateCd,
+			p.PostalCd,
+			p.ReadyTime.Format(time.RFC3339),
+			p.CloseTime.Format(time.RFC3339),
+			strconv.FormatUint(uint64(p.Pallets), 10),
+			strconv.FormatUint(uint64(p.Pieces), 10),
+			strconv.FormatUint(uint64(p.Weight), 10),
+			strconv.FormatBool(p.Hazmat),
+			p.Remarks,
+		})
+		if err != nil {
+			err = errors.Wrap(err, "xpo.WriteCSV - could not write pickup")
+			return
+		}
+	}
+
+	cw.Flush()
+	err = cw.Error()
+	if err != nil {
+		err = errors.Wrap(err, "xpo.WriteCSV - could not flush")
+		return
+	}
+
+	return
+}
+
+//WriteJSON writes the manifest as indented json
+func (m Manifest) WriteJSON(w io.Writer) (err error) {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+
+	err = e.Encode(m)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.WriteJSON - could not encode manifest")
+		return
+	}
+
+	return
+}
+
+//WriteText writes the manifest as a plain text table meant to be printed
+func (m Manifest) WriteText(w io.Writer) (err error) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "XPO PICKUP MANIFEST - %s\n\n", m.Date.Format("Mon Jan 2, 2006"))
+	fmt.Fprintln(tw, "CONFIRMATION\tSHIPPER\tCITY\tREADY\tCLOSE\tPALLETS\tPIECES\tLBS\tHAZMAT\t")
+	for _, p := range m.Pickups {
+		hazmat := ""
+		if p.Hazmat {
+			hazmat = "YES"
+		}
+
+		fmt.Fprintf(
+			tw,
+			"%s\t%s\t%s, %s\t%s\t%s\t%d\t%d\t%d\t%s\t\n",
+			p.ConfirmationNbr,
+			p.ShipperName,
+			p.City,
+			p.StateCd,
+			p.ReadyTime.Format("15:04"),
+			p.CloseTime.Format("15:04"),
+			p.Pallets,
+			p.Pieces,
+			p.Weight,
+			hazmat,
+		)
+	}
+	fmt.Fprintf(tw, "TOTAL\t%d pickups\t\t\t\t%d\t%d\t%d\t\t\n", len(m.Pickups), m.TotalPallets, m.TotalPieces, m.TotalWeight)
+
+	err = tw.Flush()
+	if err != nil {
+		err = errors.Wrap(err, "xpo.WriteText - could not write manifest")
+		return
+	}
+
+	return
+}
@@ -0,0 +1,46 @@
+package xpo
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+//cleanMessage normalizes a message from XPO so it is consistent to show to users
+//XPO messages sometimes come back as latin-1 instead of utf-8, with html entities, or with extra
+//whitespace and line breaks.
+func cleanMessage(s string) string {
+	//bytes that aren't valid utf-8 are treated as latin-1, which maps each byte to the same code point
+	if !utf8.ValidString(s) {
+		b := []byte(s)
+		runes := make([]rune, 0, len(b))
+		for len(b) > 0 {
+			r, size := utf8.DecodeRune(b)
+			if r == utf8.RuneError && size == 1 {
+				r = rune(b[0])
+			}
+			runes = append(runes, r)
+			b = b[size:]
+		}
+		s = string(runes)
+	}
+
+	s = html.UnescapeString(s)
+	s = strings.Join(strings.Fields(s), " ")
+	return s
+}
+
+//faultMessage returns the message to use for an XPO fault
+//If the client was given a translation for the fault's code that is used, otherwise the cleaned up
+//description (or message if there is no description) from XPO.
+func (c *Client) faultMessage(e ErrorPickupResponse) string {
+	if m, ok := c.messages[strings.TrimSpace(e.Code)]; ok {
+		return m
+	}
+
+	m := cleanMessage(e.Description)
+	if m == "" {
+		m = cleanMessage(e.Message)
+	}
+	return m
+}
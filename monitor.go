@@ -0,0 +1,214 @@
+package xpo
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//defaultMonitorInterval is how often watched shipments are tracked if no interval is given
+const defaultMonitorInterval = 15 * time.Minute
+
+//TrackingMonitorConfig holds the settings for a TrackingMonitor
+type TrackingMonitorConfig struct {
+	Interval time.Duration //how often to track the watched shipments, defaults to 15 minutes
+
+	//OnAlert is called once each time a watched shipment goes StuckAfter without any tracking movement, so
+	//someone can call the terminal.  See WebhookAlert.
+	OnAlert    AlertFunc
+	StuckAfter time.Duration
+}
+
+//TrackingMonitor tracks shipments every interval and raises an AlertStuck for any that stop moving
+//A shipment moves when its status changes or XPO adds a tracking event.  Time without movement is counted
+//from when the monitor saw the shipment last move, starting when it is watched, not from the event times
+//since those are local to each terminal.  Delivered shipments are no longer watched.
+type TrackingMonitor struct {
+	client     *Client
+	interval   time.Duration
+	onAlert    AlertFunc
+	stuckAfter time.Duration
+
+	mu        sync.Mutex
+	shipments map[string]*watchedShipment //keyed by normalized PRO
+}
+
+//watchedShipment is the last movement seen for a watched shipment
+type watchedShipment struct {
+	movement string    //status and newest event, changes whenever the shipment moves
+	movedAt  time.Time //when movement last changed
+	alerted  bool      //an AlertStuck was raised since the shipment last moved
+}
+
+//NewTrackingMonitor creates a monitor that tracks shipments with c
+func NewTrackingMonitor(c *Client, cfg TrackingMonitorConfig) *TrackingMonitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultMonitorInterval
+	}
+
+	return &TrackingMonitor{
+		client:     c,
+		interval:   cfg.Interval,
+		onAlert:    cfg.OnAlert,
+		stuckAfter: cfg.StuckAfter,
+		shipments:  map[string]*watchedShipment{},
+	}
+}
+
+//Watch adds shipments to track, by PRO number
+//Shipments already watched are left as is.
+func (m *TrackingMonitor) Watch(proNumbers ...string) (err error) {
+	pros := make([]string, 0, len(proNumbers))
+	for _, s := range proNumbers {
+		p, parseErr := ParsePRO(s)
+		if parseErr != nil {
+			err = errors.Wrap(parseErr, "xpo.Watch - invalid PRO number")
+			return
+		}
+		pros = append(pros, p.String())
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.client.now()
+	for _, pro := range pros {
+		if _, ok := m.shipments[pro]; !ok {
+			m.shipments[pro] = &watchedShipment{movedAt: now}
+		}
+	}
+
+	return
+}
+
+//Unwatch stops tracking shipments
+func (m *TrackingMonitor) Unwatch(proNumbers ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, s := range proNumbers {
+		if p, err := ParsePRO(s); err == nil {
+			delete(m.shipments, p.String())
+		}
+	}
+	return
+}
+
+//Watching returns the PROs being watched, sorted
+func (m *TrackingMonitor) Watching() (pros []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for pro := range m.shipments {
+		pros = append(pros, pro)
+	}
+	sort.Strings(pros)
+	return
+}
+
+//Run checks the watched shipments every interval until ctx is canceled
+//Run blocks so start it in its own goroutine.
+func (m *TrackingMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+//Check tracks every watched shipment once and raises an AlertStuck for each that hasn't moved in StuckAfter
+//A shipment that couldn't be tracked is logged and checked again next time.  Nothing is alerted if OnAlert or
+//StuckAfter wasn't given, shipments are still tracked so status changes are published.
+func (m *TrackingMonitor) Check(ctx context.Context) {
+	pros := m.Watching()
+	if len(pros) == 0 {
+		return
+	}
+
+	results := m.client.TrackByPRO(ctx, pros...)
+	now := m.client.now()
+
+	var alerts []Alert
+	m.mu.Lock()
+	for i, r := range results {
+		w, ok := m.shipments[pros[i]]
+		switch {
+		case !ok:
+			//unwatched while being tracked
+			continue
+		case r.Err != nil:
+			m.client.logf("xpo.Check - could not track %s: %v", pros[i], r.Err)
+			continue
+		case r.Value.delivered():
+			delete(m.shipments, pros[i])
+			continue
+		}
+
+		//the first time a shipment is tracked is counted from when it was watched
+		if movement := r.Value.movement(); movement != w.movement {
+			if w.movement != "" {
+				w.movedAt = now
+				w.alerted = false
+			}
+			w.movement = movement
+		}
+
+		if m.onAlert == nil || m.stuckAfter <= 0 || w.alerted || now.Sub(w.movedAt) < m.stuckAfter {
+			continue
+		}
+
+		alerts = append(alerts, Alert{
+			Kind:   AlertStuck,
+			Time:   now,
+			Tenant: m.client.tenant,
+			PRO:    pros[i],
+			Status: r.Value.Status.Code,
+			Since:  w.movedAt,
+		})
+	}
+	m.mu.Unlock()
+
+	//alerts are sent without holding the lock since a webhook can be slow
+	for _, a := range alerts {
+		alertErr := m.onAlert(ctx, a)
+		if alertErr != nil {
+			//try again next time
+			m.client.logf("xpo.Check - could not alert for stuck shipment %s: %v", a.PRO, alertErr)
+			continue
+		}
+
+		m.mu.Lock()
+		if w, ok := m.shipments[a.PRO]; ok && w.movedAt.Equal(a.Since) {
+			w.alerted = true
+		}
+		m.mu.Unlock()
+	}
+
+	return
+}
+
+//movement summarizes where a shipment is at, it changes whenever the shipment moves
+func (sh Shipment) movement() string {
+	s := sh.Status.Code
+	if n := len(sh.Events); n > 0 {
+		last := sh.Events[n-1]
+		s += "|" + last.Time.String() + "|" + last.Code
+	}
+
+	return s
+}
+
+//delivered checks if a shipment has been delivered
+func (sh Shipment) delivered() bool {
+	return !sh.DeliveryDate.IsZero() || sh.Status.Code == "DELIVERED"
+}
@@ -0,0 +1,85 @@
+package xpo
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTrackingMonitorStuck(t *testing.T) {
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	fake := &fakeXPO{}
+	c, err := NewClient(Config{
+		Username:    "user",
+		Password:    "secret",
+		AccessToken: "access",
+		HTTPClient:  &http.Client{Transport: fake},
+		Now:         func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var alerts []Alert
+	m := NewTrackingMonitor(c, TrackingMonitorConfig{
+		StuckAfter: 4 * time.Hour,
+		OnAlert: func(ctx context.Context, a Alert) error {
+			alerts = append(alerts, a)
+			return nil
+		},
+	})
+
+	shipment := Shipment{
+		PRO:    "123-456789",
+		Status: ShipmentStatus{Code: "IN_TRANSIT"},
+		Events: []TrackingEvent{{Time: NewTime(now.Add(-time.Hour)), Code: "PICKED_UP"}},
+	}
+	fake.setShipment("123-456789", shipment)
+
+	err = m.Watch("123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	m.Check(ctx)
+	now = now.Add(3 * time.Hour)
+	m.Check(ctx)
+	if len(alerts) != 0 {
+		t.Fatalf("got %d alerts before StuckAfter, want 0", len(alerts))
+	}
+
+	now = now.Add(2 * time.Hour)
+	m.Check(ctx)
+	m.Check(ctx)
+	if len(alerts) != 1 {
+		t.Fatalf("got %d alerts, want 1 for a shipment stuck 5 hours", len(alerts))
+	}
+	if a := alerts[0]; a.Kind != AlertStuck || a.PRO != "123-456789" || a.Status != "IN_TRANSIT" || !a.Since.Equal(now.Add(-5*time.Hour)) {
+		t.Errorf("got alert %+v", a)
+	}
+
+	//moving again starts the clock over
+	shipment.Events = append(shipment.Events, TrackingEvent{Time: NewTime(now), Code: "ARRIVED"})
+	fake.setShipment("123-456789", shipment)
+	m.Check(ctx)
+	now = now.Add(3 * time.Hour)
+	m.Check(ctx)
+	if len(alerts) != 1 {
+		t.Errorf("got %d alerts, want none after the shipment moved", len(alerts)-1)
+	}
+
+	now = now.Add(2 * time.Hour)
+	m.Check(ctx)
+	if len(alerts) != 2 {
+		t.Errorf("got %d alerts, want a second once it is stuck again", len(alerts))
+	}
+
+	shipment.Status.Code = "DELIVERED"
+	fake.setShipment("123-456789", shipment)
+	m.Check(ctx)
+	if pros := m.Watching(); len(pros) != 0 {
+		t.Errorf("got %q watched, want delivered shipments dropped", pros)
+	}
+}
@@ -0,0 +1,428 @@
+package xpo
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreymgilmore/xpologistics/transport"
+	"github.com/pkg/errors"
+)
+
+//defaultOutboxInterval is how often queued pickups are retried if no interval is given
+const defaultOutboxInterval = 1 * time.Minute
+
+//QueuedPickup is a pickup request waiting to be sent to XPO
+type QueuedPickup struct {
+	ID        string
+	RecordID  string //the pickup saved to the client's PickupStore, updated when the pickup is sent
+	QueuedAt  time.Time
+	Attempts  int       //number of times sending has been tried, including the first try before it was queued
+	LastError string    //why the last try failed
+	AlertedAt time.Time //when an AlertUnconfirmed was raised for the pickup, zero if not yet
+	Request   PickupRqstInfo
+
+	//copied from the request since they are not part of what is saved with it
+	Accounting Accounting
+	Metadata   map[string]string
+}
+
+//PickupQueue persists pickups that could not be sent because XPO was unreachable
+//Pending must return pickups in the order they were queued.
+type PickupQueue interface {
+	Enqueue(ctx context.Context, p QueuedPickup) error
+	Pending(ctx context.Context) ([]QueuedPickup, error)
+	Update(ctx context.Context, p QueuedPickup) error
+	Remove(ctx context.Context, id string) error
+}
+
+//OutboxResultFunc is called when a queued pickup is finally sent to XPO
+//err is set if XPO rejected the pickup, otherwise response holds the confirmation.
+type OutboxResultFunc func(p QueuedPickup, response SuccessfulPickupResponse, err error)
+
+//OutboxConfig holds the settings for an Outbox
+type OutboxConfig struct {
+	Interval time.Duration    //how often to retry queued pickups, defaults to 1 minute
+	OnResult OutboxResultFunc //called with the result of each queued pickup
+
+	//OnAlert is called once for each pickup still queued AlertAfter since it was submitted, so someone can
+	//book it by phone instead.  See WebhookAlert.
+	OnAlert    AlertFunc
+	AlertAfter time.Duration
+}
+
+//Outbox requests pickups and queues them when XPO cannot be reached
+//Queued pickups are retried in order by Run() once XPO is reachable again.  Pickups that XPO rejects are
+//never queued, the error is returned right away.  Neither are pickups that may have reached XPO, like when the
+//request timed out waiting on a response, since the pickup may be booked; check for those by hand.
+type Outbox struct {
+	client   *Client
+	queue    PickupQueue
+	interval time.Duration
+	onResult OutboxResultFunc
+
+	onAlert    AlertFunc
+	alertAfter time.Duration
+
+	//only one run of the queue at a time so pickups aren't sent twice
+	mu sync.Mutex
+}
+
+//NewOutbox creates an outbox that requests pickups with c and queues them to q
+func NewOutbox(c *Client, q PickupQueue, cfg OutboxConfig) *Outbox {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultOutboxInterval
+	}
+
+	return &Outbox{
+		client:     c,
+		queue:      q,
+		interval:   cfg.Interval,
+		onResult:   cfg.OnResult,
+		onAlert:    cfg.OnAlert,
+		alertAfter: cfg.AlertAfter,
+	}
+}
+
+//Submit requests a pickup, queueing it if XPO could not be reached
+//queued is true when the pickup was saved to the queue instead of being requested.  In that case the result
+//is reported to OnResult once the pickup is sent.  With a PickupStore the pickup is saved once, as queued, and
+//that same record is updated when the pickup is finally sent.
+func (o *Outbox) Submit(ctx context.Context, pri *PickupRqstInfo) (response SuccessfulPickupResponse, queued bool, err error) {
+	response, recordID, err := o.client.requestPickup(ctx, pri, pickupAttempt{queued: true})
+	if err == nil || !isUnreachable(err) {
+		return
+	}
+
+	//the stored pickup says queued, it failed for good if it can't actually be queued
+	defer func() {
+		if err != nil && recordID != "" {
+			o.client.updatePickup(context.WithoutCancel(ctx), recordID, response, err, false)
+		}
+	}()
+
+	id, idErr := newRecordID()
+	if idErr != nil {
+		err = errors.Wrap(idErr, "xpo.Submit - could not generate queue id")
+		return
+	}
+
+	p := QueuedPickup{
+		ID:         id,
+		RecordID:   recordID,
+		QueuedAt:   o.client.now(),
+		Attempts:   1,
+		LastError:  err.Error(),
+		Request:    *pri,
+		Accounting: pri.Accounting,
+		Metadata:   pri.Metadata,
+	}
+	qErr := o.queue.Enqueue(ctx, p)
+	if qErr != nil {
+		err = errors.Wrap(qErr, "xpo.Submit - XPO unreachable and pickup could not be queued")
+		return
+	}
+
+	o.client.logf("xpo.Submit - XPO unreachable, pickup queued as %s: %v", id, err)
+	queued = true
+	err = nil
+	return
+}
+
+//Run retries queued pickups every interval until ctx is canceled
+//Run blocks so start it in its own goroutine.
+func (o *Outbox) Run(ctx context.Context) {
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+
+	for {
+		err := o.Flush(ctx)
+		if err != nil {
+			o.client.logf("xpo.Run - %v", err)
+		}
+
+		err = o.Alert(ctx)
+		if err != nil {
+			o.client.logf("xpo.Run - %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+//Flush tries to send every queued pickup, in order
+//Sending stops at the first pickup that still cannot reach XPO since the rest would fail too.
+func (o *Outbox) Flush(ctx context.Context) (err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	pending, err := o.queue.Pending(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.Flush - could not get queued pickups")
+		return
+	}
+
+	for _, p := range pending {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		p.Attempts++
+		p.Request.Accounting = p.Accounting
+		p.Request.Metadata = p.Metadata
+		response, recordID, reqErr := o.client.requestPickup(ctx, &p.Request, pickupAttempt{recordID: p.RecordID, queued: true})
+		p.RecordID = recordID
+		if reqErr != nil && isUnreachable(reqErr) {
+			p.LastError = reqErr.Error()
+			err = o.queue.Update(ctx, p)
+			if err != nil {
+				err = errors.Wrap(err, "xpo.Flush - could not update queued pickup")
+			}
+			return
+		}
+
+		//sent, either booked or rejected, so it should not be tried again
+		err = o.queue.Remove(ctx, p.ID)
+		if err != nil {
+			err = errors.Wrap(err, "xpo.Flush - could not remove sent pickup from queue")
+			return
+		}
+
+		if o.onResult != nil {
+			o.onResult(p, response, reqErr)
+		}
+	}
+
+	return
+}
+
+//Alert raises an AlertUnconfirmed for each pickup queued longer than AlertAfter
+//Each pickup is only alerted on once.  Nothing is done if OnAlert or AlertAfter wasn't given.
+func (o *Outbox) Alert(ctx context.Context) (err error) {
+	if o.onAlert == nil || o.alertAfter <= 0 {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	pending, err := o.queue.Pending(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.Alert - could not get queued pickups")
+		return
+	}
+
+	now := o.client.now()
+	for _, p := range pending {
+		if !p.AlertedAt.IsZero() || now.Sub(p.QueuedAt) < o.alertAfter {
+			continue
+		}
+
+		a := Alert{
+			Kind:      AlertUnconfirmed,
+			Time:      now,
+			Tenant:    o.client.tenant,
+			ID:        p.ID,
+			Since:     p.QueuedAt,
+			Attempts:  p.Attempts,
+			LastError: p.LastError,
+			Metadata:  p.Metadata,
+		}
+		alertErr := o.onAlert(ctx, a)
+		if alertErr != nil {
+			//try again next time
+			o.client.logf("xpo.Alert - could not alert for queued pickup %s: %v", p.ID, alertErr)
+			continue
+		}
+
+		p.AlertedAt = now
+		err = o.queue.Update(ctx, p)
+		if err != nil {
+			err = errors.Wrap(err, "xpo.Alert - could not update queued pickup")
+			return
+		}
+	}
+
+	return
+}
+
+//isUnreachable checks if a request never reached XPO, or XPO was down for maintenance, so it is safe to send
+//again
+//Only DNS and connection failures count, the request was never sent.  A timeout or reset may have happened
+//after XPO got the request and booked the pickup, so sending it again could book it twice.
+func isUnreachable(err error) bool {
+	var netErr *NetworkError
+	if errors.As(err, &netErr) && (netErr.Kind == transport.NetworkDNS || netErr.Kind == transport.NetworkConnect) {
+		return true
+	}
+
+	return errors.Is(err, ErrMaintenance)
+}
+
+//MemoryPickupQueue is a PickupQueue held in memory
+//Queued pickups are lost when the program exits; use DirPickupQueue to keep them across restarts.
+type MemoryPickupQueue struct {
+	mu      sync.Mutex
+	pickups []QueuedPickup
+}
+
+//Enqueue adds a pickup to the end of the queue
+func (m *MemoryPickupQueue) Enqueue(ctx context.Context, p QueuedPickup) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pickups = append(m.pickups, p)
+	return nil
+}
+
+//Pending returns a copy of the queued pickups
+func (m *MemoryPickupQueue) Pending(ctx context.Context) ([]QueuedPickup, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]QueuedPickup(nil), m.pickups...), nil
+}
+
+//Update replaces a queued pickup
+func (m *MemoryPickupQueue) Update(ctx context.Context, p QueuedPickup) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, v := range m.pickups {
+		if v.ID == p.ID {
+			m.pickups[i] = p
+			return nil
+		}
+	}
+
+	return errors.New("xpo.Update - queued pickup not found")
+}
+
+//Remove takes a pickup out of the queue
+func (m *MemoryPickupQueue) Remove(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, v := range m.pickups {
+		if v.ID == id {
+			m.pickups = append(m.pickups[:i], m.pickups[i+1:]...)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+//DirPickupQueue is a PickupQueue that saves each queued pickup as a json file in a directory
+type DirPickupQueue struct {
+	dir string
+	mu  sync.Mutex
+}
+
+//NewDirPickupQueue creates the directory, if needed, and returns a queue using it
+func NewDirPickupQueue(dir string) (q *DirPickupQueue, err error) {
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.NewDirPickupQueue - could not create directory")
+		return
+	}
+
+	q = &DirPickupQueue{
+		dir: dir,
+	}
+	return
+}
+
+//Enqueue writes a pickup to the directory
+func (d *DirPickupQueue) Enqueue(ctx context.Context, p QueuedPickup) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.write(p)
+}
+
+//Pending reads every queued pickup from the directory, oldest first
+func (d *DirPickupQueue) Pending(ctx context.Context) (pickups []QueuedPickup, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(d.dir, "*.json"))
+	if err != nil {
+		return
+	}
+
+	for _, f := range files {
+		b, readErr := ioutil.ReadFile(f)
+		if readErr != nil {
+			err = errors.Wrap(readErr, "xpo.Pending - could not read queued pickup")
+			return
+		}
+
+		var p QueuedPickup
+		err = json.Unmarshal(b, &p)
+		if err != nil {
+			err = errors.Wrapf(err, "xpo.Pending - could not unmarshal queued pickup %s", f)
+			return
+		}
+		pickups = append(pickups, p)
+	}
+
+	sort.SliceStable(pickups, func(i, j int) bool { return pickups[i].QueuedAt.Before(pickups[j].QueuedAt) })
+	return
+}
+
+//Update overwrites a queued pickup's file
+func (d *DirPickupQueue) Update(ctx context.Context, p QueuedPickup) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.write(p)
+}
+
+//Remove deletes a queued pickup's file
+func (d *DirPickupQueue) Remove(ctx context.Context, id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	err := os.Remove(d.path(id))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+//path returns the file a queued pickup is saved to
+//ids are generated by us but are still cleaned so nothing can be written outside the directory
+func (d *DirPickupQueue) path(id string) string {
+	id = strings.NewReplacer("/", "", "\\", "", "..", "").Replace(id)
+	return filepath.Join(d.dir, id+".json")
+}
+
+//write saves a pickup to a temporary file and renames it so a crash never leaves a partial file
+func (d *DirPickupQueue) write(p QueuedPickup) (err error) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return
+	}
+
+	path := d.path(p.ID)
+	tmp := path + ".tmp"
+	err = ioutil.WriteFile(tmp, b, 0600)
+	if err != nil {
+		return
+	}
+
+	err = os.Rename(tmp, path)
+	return
+}
@@ -0,0 +1,264 @@
+package xpo
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+//hungClient returns an http client whose connections never complete, like a link that drops every packet
+func hungClient() *http.Client {
+	return &http.Client{
+		Timeout: 50 * time.Millisecond,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+		},
+	}
+}
+
+func TestOutboxSubmitQueuesWhenDialHangs(t *testing.T) {
+	c, err := NewClient(Config{
+		Username:    "user",
+		Password:    "secret",
+		AccessToken: "access",
+		HTTPClient:  hungClient(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := &MemoryPickupQueue{}
+	o := NewOutbox(c, q, OutboxConfig{})
+
+	pri := testPickup()
+	_, queued, err := o.Submit(context.Background(), &pri)
+	if err != nil {
+		t.Fatalf("got %v, want the pickup queued", err)
+	}
+	if !queued {
+		t.Fatal("got queued false, want true")
+	}
+
+	pending, _ := q.Pending(context.Background())
+	if len(pending) != 1 {
+		t.Fatalf("got %d queued pickups, want 1", len(pending))
+	}
+}
+
+func TestOutboxKeepsOneRecord(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeXPO{down: true}
+	store := &memoryPickupStore{}
+	q := &MemoryPickupQueue{}
+
+	var results int
+	o := NewOutbox(fakeClient(fake, store), q, OutboxConfig{
+		OnResult: func(p QueuedPickup, response SuccessfulPickupResponse, err error) {
+			results++
+			if err != nil {
+				t.Errorf("got %v, want the queued pickup booked", err)
+			}
+		},
+	})
+
+	pri := testPickup()
+	_, queued, err := o.Submit(ctx, &pri)
+	if err != nil || !queued {
+		t.Fatalf("got queued %t, %v, want the pickup queued", queued, err)
+	}
+
+	records := store.all()
+	if len(records) != 1 || records[0].Status != PickupStatusQueued {
+		t.Fatalf("got records %+v, want one queued record", records)
+	}
+
+	//still down, the same record stays queued
+	err = o.Flush(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records = store.all(); len(records) != 1 || records[0].Status != PickupStatusQueued {
+		t.Fatalf("got records %+v, want one queued record", records)
+	}
+
+	fake.setDown(false)
+	err = o.Flush(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records = store.all()
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want the queued record updated", len(records))
+	}
+	if records[0].Status != PickupStatusConfirmed || records[0].ConfirmationNbr == "" {
+		t.Errorf("got status %s, confirmation %q, want confirmed", records[0].Status, records[0].ConfirmationNbr)
+	}
+	if results != 1 || fake.pickupCount() != 1 {
+		t.Errorf("got %d results and %d pickups sent, want 1 of each", results, fake.pickupCount())
+	}
+
+	pending, _ := q.Pending(ctx)
+	if len(pending) != 0 {
+		t.Errorf("got %d queued pickups after flushing, want 0", len(pending))
+	}
+}
+
+func TestOutboxSubmitNotQueued(t *testing.T) {
+	tests := []struct {
+		name   string
+		fake   *fakeXPO
+		status PickupStatus
+	}{
+		{name: "rejected", fake: &fakeXPO{pickupFault: "400"}, status: PickupStatusFailed},
+		{name: "may have been sent", fake: &fakeXPO{pickupErr: io.ErrUnexpectedEOF}, status: PickupStatusUnknown},
+	}
+
+	for _, tt := range tests {
+		store := &memoryPickupStore{}
+		q := &MemoryPickupQueue{}
+		o := NewOutbox(fakeClient(tt.fake, store), q, OutboxConfig{})
+
+		pri := testPickup()
+		_, queued, err := o.Submit(context.Background(), &pri)
+		if err == nil || queued {
+			t.Errorf("%s: got queued %t, %v, want the error returned", tt.name, queued, err)
+		}
+
+		pending, _ := q.Pending(context.Background())
+		if len(pending) != 0 {
+			t.Errorf("%s: got %d queued pickups, want 0", tt.name, len(pending))
+		}
+		if records := store.all(); len(records) != 1 || records[0].Status != tt.status {
+			t.Errorf("%s: got records %+v, want one %s record", tt.name, records, tt.status)
+		}
+	}
+}
+
+func TestOutboxFlushInOrder(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeXPO{down: true}
+	q := &MemoryPickupQueue{}
+
+	var sent []string
+	o := NewOutbox(fakeClient(fake, nil), q, OutboxConfig{
+		OnResult: func(p QueuedPickup, response SuccessfulPickupResponse, err error) {
+			sent = append(sent, p.Request.Remarks)
+		},
+	})
+
+	for _, remarks := range []string{"first", "second"} {
+		pri := testPickup()
+		pri.Remarks = remarks
+		_, queued, err := o.Submit(ctx, &pri)
+		if err != nil || !queued {
+			t.Fatalf("got queued %t, %v, want the pickup queued", queued, err)
+		}
+	}
+
+	//the first pickup fails so the second isn't tried
+	err := o.Flush(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending, _ := q.Pending(ctx)
+	if len(pending) != 2 || pending[0].Attempts != 2 || pending[1].Attempts != 1 {
+		t.Fatalf("got %+v, want only the first pickup tried again", pending)
+	}
+
+	fake.setDown(false)
+	err = o.Flush(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 2 || !strings.HasPrefix(sent[0], "first") || !strings.HasPrefix(sent[1], "second") {
+		t.Errorf("got %q sent, want both pickups in the order they were queued", sent)
+	}
+}
+
+func TestOutboxAlert(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	c, err := NewClient(Config{
+		Username:    "user",
+		Password:    "secret",
+		AccessToken: "access",
+		HTTPClient:  &http.Client{Transport: &fakeXPO{down: true}},
+		Now:         func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var alerts []Alert
+	q := &MemoryPickupQueue{}
+	o := NewOutbox(c, q, OutboxConfig{
+		AlertAfter: time.Hour,
+		OnAlert: func(ctx context.Context, a Alert) error {
+			alerts = append(alerts, a)
+			return nil
+		},
+	})
+
+	pri := testPickup()
+	_, _, err = o.Submit(ctx, &pri)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(30 * time.Minute)
+	o.Alert(ctx)
+	if len(alerts) != 0 {
+		t.Fatalf("got %d alerts before AlertAfter, want 0", len(alerts))
+	}
+
+	now = now.Add(time.Hour)
+	o.Alert(ctx)
+	o.Alert(ctx)
+	if len(alerts) != 1 {
+		t.Fatalf("got %d alerts, want 1 for a pickup queued 90 minutes", len(alerts))
+	}
+	if a := alerts[0]; a.Kind != AlertUnconfirmed || a.Attempts != 1 || !a.Since.Equal(now.Add(-90*time.Minute)) {
+		t.Errorf("got alert %+v", a)
+	}
+}
+
+func TestDirPickupQueue(t *testing.T) {
+	ctx := context.Background()
+	q, err := NewDirPickupQueue(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	for i, id := range []string{"b", "a", "c"} {
+		err = q.Enqueue(ctx, QueuedPickup{ID: id, QueuedAt: start.Add(time.Duration(i) * time.Minute), Request: testPickup()})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = q.Update(ctx, QueuedPickup{ID: "a", QueuedAt: start.Add(time.Minute), Attempts: 2, Request: testPickup()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = q.Remove(ctx, "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := q.Pending(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 || pending[0].ID != "b" || pending[1].ID != "a" || pending[1].Attempts != 2 {
+		t.Errorf("got %+v, want b then the updated a", pending)
+	}
+}
@@ -0,0 +1,86 @@
+package xpo
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//dialing codes for the countries XPO services
+const (
+	DialingCodeNANP   = "1" //US and Canada
+	DialingCodeMexico = "52"
+)
+
+//digits strips everything but 0-9 from a string
+func digits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+//NewPhone parses a phone number for the given dialing code into a Phone
+//The number can be formatted any way ("(503) 555-1212", "+52 55 1234 5678", etc.).  A leading dialing code in
+//the number is removed.  US and Canadian numbers are formatted 999-9999999, other numbers are kept as digits.
+func NewPhone(countryCd, number, extension string) (p Phone, err error) {
+	countryCd = digits(countryCd)
+	if countryCd == "" {
+		countryCd = DialingCodeNANP
+	}
+
+	n := digits(number)
+	if strings.HasPrefix(strings.TrimSpace(number), "+") {
+		if !strings.HasPrefix(n, countryCd) {
+			err = errors.New("xpo.NewPhone - number has a different country code than " + countryCd)
+			return
+		}
+		n = strings.TrimPrefix(n, countryCd)
+	}
+	if countryCd == DialingCodeNANP && len(n) == 11 && strings.HasPrefix(n, "1") {
+		n = n[1:]
+	}
+
+	p = Phone{
+		CountryCd: countryCd,
+		PhoneNbr:  n,
+		Extension: digits(extension),
+	}
+	if countryCd == DialingCodeNANP && len(n) == 10 {
+		p.PhoneNbr = n[:3] + "-" + n[3:]
+	}
+
+	err = p.Validate()
+	return
+}
+
+//Validate checks that a phone number is the right length for its country
+//A phone without a CountryCd is treated as a US/Canadian number.
+func (p Phone) Validate() error {
+	countryCd := p.CountryCd
+	if countryCd == "" {
+		countryCd = DialingCodeNANP
+	}
+
+	n := digits(p.PhoneNbr)
+	switch countryCd {
+	case DialingCodeNANP:
+		//area code and exchange can't start with 0 or 1
+		if len(n) != 10 || n[0] < '2' || n[3] < '2' {
+			return errors.New("xpo - phone number " + p.PhoneNbr + " is not a valid US/Canadian number")
+		}
+	case DialingCodeMexico:
+		if len(n) != 10 {
+			return errors.New("xpo - phone number " + p.PhoneNbr + " is not a valid Mexican number")
+		}
+	default:
+		//E.164 allows 15 digits including the country code
+		if len(n) < 4 || len(n)+len(countryCd) > 15 {
+			return errors.New("xpo - phone number " + p.PhoneNbr + " is not a valid international number")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package xpo
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+//Registry holds one client per tenant for services that book pickups for many customers' XPO accounts
+//Each tenant's client has its own credentials, tokens, rate limit, and stats so one customer can't affect
+//another.  Share a TokenStore between tenants if you like, tokens are keyed by tenant.
+type Registry struct {
+	mu      sync.RWMutex
+	clients map[string]*Client
+}
+
+//NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		clients: map[string]*Client{},
+	}
+}
+
+//Register creates a client for tenant, replacing any client already registered for it
+//cfg.Tenant is set to tenant.
+func (r *Registry) Register(tenant string, cfg Config) (c *Client, err error) {
+	if tenant == "" {
+		err = errors.New("xpo.Register - tenant is required")
+		return
+	}
+
+	cfg.Tenant = tenant
+	c, err = NewClient(cfg)
+	if err != nil {
+		err = errors.Wrapf(err, "xpo.Register - tenant %s", tenant)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.clients[tenant] = c
+	return
+}
+
+//Client returns the client registered for tenant
+func (r *Registry) Client(tenant string) (c *Client, ok bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok = r.clients[tenant]
+	return
+}
+
+//Remove drops the client registered for tenant
+func (r *Registry) Remove(tenant string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.clients, tenant)
+	return
+}
+
+//Tenants returns every registered tenant, sorted
+func (r *Registry) Tenants() (tenants []string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenants = make([]string, 0, len(r.clients))
+	for t := range r.clients {
+		tenants = append(tenants, t)
+	}
+	sort.Strings(tenants)
+	return
+}
+
+//LatencyStats returns the latency stats of every registered client, sorted by tenant then endpoint
+func (r *Registry) LatencyStats() (s []LatencyStats) {
+	for _, t := range r.Tenants() {
+		c, ok := r.Client(t)
+		if !ok {
+			continue
+		}
+
+		s = append(s, c.LatencyStats()...)
+	}
+	return
+}
@@ -0,0 +1,164 @@
+package xpo
+
+import (
+	"strings"
+)
+
+//remarksSeparator separates each note added to the pickup remarks
+const remarksSeparator = " | "
+
+//MaxRemarksLength is the longest remarks this package sends to XPO
+//XPO cuts off long remarks without saying so, which can lose whatever was at the end.  RemarksBuilder drops
+//whole notes that don't fit instead so what is sent is always readable.
+const MaxRemarksLength = 500
+
+//RemarksBuilder composes notes into one remarks field
+//Notes are kept in the order they are added, so add the most important first.  Notes that would make the
+//remarks longer than MaxLen are dropped and can be checked with Dropped.  The first note is always kept, even
+//if it is too long by itself, so a caller's own remarks are never lost.
+type RemarksBuilder struct {
+	MaxLen int //defaults to MaxRemarksLength
+
+	notes   []string
+	length  int
+	dropped []string
+}
+
+//NewRemarksBuilder returns an empty builder limited to MaxRemarksLength
+func NewRemarksBuilder() *RemarksBuilder {
+	return &RemarksBuilder{
+		MaxLen: MaxRemarksLength,
+	}
+}
+
+//Add adds a note, blank notes are ignored
+func (b *RemarksBuilder) Add(note string) *RemarksBuilder {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return b
+	}
+
+	max := b.MaxLen
+	if max <= 0 {
+		max = MaxRemarksLength
+	}
+
+	length := b.length + len(note)
+	if len(b.notes) > 0 {
+		length += len(remarksSeparator)
+	}
+	if length > max && len(b.notes) > 0 {
+		b.dropped = append(b.dropped, note)
+		return b
+	}
+
+	b.notes = append(b.notes, note)
+	b.length = length
+	return b
+}
+
+//DockDoor adds the door the driver should back into
+func (b *RemarksBuilder) DockDoor(door string) *RemarksBuilder {
+	if strings.TrimSpace(door) == "" {
+		return b
+	}
+
+	return b.Add("DOOR " + door)
+}
+
+//AppointmentContact adds who to call to set up an appointment
+func (b *RemarksBuilder) AppointmentContact(c Contact) *RemarksBuilder {
+	return b.Add(RoleContact{Role: "APPOINTMENT", Contact: c}.String())
+}
+
+//CallOnArrival asks the driver to call a number when they get to the shipper
+func (b *RemarksBuilder) CallOnArrival(phone string) *RemarksBuilder {
+	if strings.TrimSpace(phone) == "" {
+		return b
+	}
+
+	return b.Add("CALL ON ARRIVAL " + phone)
+}
+
+//String returns the composed remarks
+func (b *RemarksBuilder) String() string {
+	return strings.Join(b.notes, remarksSeparator)
+}
+
+//Dropped returns the notes that didn't fit
+func (b *RemarksBuilder) Dropped() []string {
+	return b.dropped
+}
+
+//remarks composes the pickup remarks
+//The required notes come first so they are never dropped for space, then the caller's remarks, then the
+//shipper's dock hours, door, and driver instructions, then every contact in Contacts with its role that wasn't
+//used as the Contact.
+func (pri PickupRqstInfo) remarks() *RemarksBuilder {
+	b := NewRemarksBuilder()
+	for _, n := range pri.requiredRemarks() {
+		b.Add(n)
+	}
+	b.Add(pri.Remarks)
+	pri.Shipper.addDockNotes(b)
+
+	contacts := pri.Contacts
+	if pri.Contact == (Contact{}) && len(contacts) > 0 {
+		contacts = contacts[1:]
+	}
+	for _, c := range contacts {
+		b.Add(c.String())
+	}
+
+	return b
+}
+
+//requiredRemarks returns the notes drivers refuse freight without, the hazmat emergency contact and permit
+//load flag
+func (pri PickupRqstInfo) requiredRemarks() (notes []string) {
+	if h := pri.HazmatEmergency.String(); h != "" {
+		notes = append(notes, h)
+	}
+	if pri.PermitLoadInd {
+		notes = append(notes, "OVERWEIGHT PERMIT LOAD")
+	}
+	return
+}
+
+//remarks composes the item remarks from the caller's remarks and the item's handling flags
+func (i PkupItem) remarks() *RemarksBuilder {
+	b := NewRemarksBuilder()
+	b.Add(i.Remarks)
+	if i.DoNotStackInd {
+		b.Add("DO NOT STACK")
+	}
+	if i.OversizeInd {
+		b.Add("OVERSIZE")
+	}
+	if i.LimitedQtyInd {
+		b.Add("LIMITED QUANTITY")
+	}
+	for _, n := range i.FoodHandling.notes() {
+		b.Add(n)
+	}
+	if i.TempSensitiveInd {
+		if i.TempRange.IsZero() {
+			b.Add("TEMP SENSITIVE")
+		} else {
+			b.Add("TEMP SENSITIVE " + i.TempRange.String())
+		}
+	}
+	b.Add(i.BulkLiquid.String())
+
+	return b
+}
+
+//addDockNotes adds the shipper's dock info to the remarks
+func (s Shipper) addDockNotes(b *RemarksBuilder) {
+	b.Add(s.DockHours.String())
+	b.DockDoor(s.DockDoor)
+	if i := strings.TrimSpace(s.DriverInstructions); i != "" {
+		b.Add("DRIVER: " + i)
+	}
+	return
+}
@@ -0,0 +1,146 @@
+package xpo
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//MetadataReplayOf is the metadata key set to the id of the stored pickup a replayed pickup was copied from
+const MetadataReplayOf = "replayOf"
+
+//ReplaySource is where failed pickups are read from for replaying
+//SQLitePickupStore implements this.
+type ReplaySource interface {
+	Pickups(ctx context.Context, from, to time.Time) ([]PickupRecord, error)
+}
+
+//ReplayOptions changes failed pickups before they are sent again
+type ReplayOptions struct {
+	PkupDate Date                            //moves the pickup to this day, keeping its ready and close times of day
+	Patch    func(pri *PickupRqstInfo) error //any other changes, called after PkupDate is applied
+
+	//Confirmed replays records that may have been booked, any status other than failed, like unknown or
+	//queued.  Only set this after checking with XPO that those pickups weren't booked.
+	Confirmed bool
+}
+
+//ErrReplayUnconfirmed is returned by Replay for a record that may have been booked already, see
+//ReplayOptions.Confirmed
+var ErrReplayUnconfirmed = errors.New("xpo - pickup may have been booked, confirm it wasn't before replaying")
+
+//FailedPickups returns the pickups in src requested between from and to that failed and weren't booked since
+//Only pickups that certainly weren't booked are returned, see PickupStatusFailed.  Pickups with an unknown
+//outcome, or still requested, may have been booked by XPO and have to be checked by hand.  A failed pickup is
+//also left out if it was replayed, or the same pickup was requested in another record, and that wasn't
+//rejected too, so replaying what is returned doesn't book anything twice.
+func FailedPickups(ctx context.Context, src ReplaySource, from, to time.Time) (failed []PickupRecord, err error) {
+	records, err := src.Pickups(ctx, from, to)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.FailedPickups - could not get pickups")
+		return
+	}
+
+	//pickups requested again, keyed by the id of the record replayed and by the request itself
+	retried := map[string]bool{}
+	for _, r := range records {
+		if r.Status == PickupStatusFailed {
+			continue
+		}
+		if id := r.Metadata[MetadataReplayOf]; id != "" {
+			retried[id] = true
+		}
+		retried[r.requestKey()] = true
+	}
+
+	for _, r := range records {
+		if r.Status != PickupStatusFailed || retried[r.ID] || retried[r.requestKey()] {
+			continue
+		}
+		failed = append(failed, r)
+	}
+
+	return
+}
+
+//requestKey identifies the pickup a record requested, regardless of metadata, so the same pickup requested
+//twice can be matched
+func (r PickupRecord) requestKey() string {
+	pri := r.Request
+	pri.Metadata = nil
+	b, err := json.Marshal(pri)
+	if err != nil {
+		return r.ID
+	}
+
+	return "request:" + string(b)
+}
+
+//Replay requests saved pickups again, like ones that failed, with the client's credentials
+//Each pickup is patched with opts and then requested as a new pickup, the Index of each result is its
+//position in records.  The record id is kept in the new pickup's metadata under MetadataReplayOf.  A pickup
+//that fails to patch isn't requested.  Neither is a record with a status other than failed, it fails with
+//ErrReplayUnconfirmed, unless opts.Confirmed is set.  Records without a status, like pickups read from files,
+//are requested.
+func (c *Client) Replay(ctx context.Context, records []PickupRecord, opts ReplayOptions) (results Results[SuccessfulPickupResponse]) {
+	results = make(Results[SuccessfulPickupResponse], len(records))
+	for i, r := range records {
+		results[i].Index = i
+
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		if r.Status != "" && r.Status != PickupStatusFailed && !opts.Confirmed {
+			results[i].Err = errors.Wrapf(ErrReplayUnconfirmed, "xpo.Replay - pickup %s is %s", r.ID, r.Status)
+			continue
+		}
+
+		pri, err := r.ReplayRequest(opts)
+		if err != nil {
+			results[i].Err = errors.Wrapf(err, "xpo.Replay - could not patch pickup %s", r.ID)
+			continue
+		}
+
+		results[i].Value, results[i].Err = c.RequestPickup(ctx, &pri)
+	}
+
+	return
+}
+
+//ReplayRequest builds the pickup request Replay sends for a record
+//Use this to see what would be sent before replaying.
+func (r PickupRecord) ReplayRequest(opts ReplayOptions) (pri PickupRqstInfo, err error) {
+	pri = r.Request
+	pri.Accounting = r.Accounting
+	pri.PkupItem = append([]PkupItem(nil), r.Request.PkupItem...)
+	pri.Contacts = append([]RoleContact(nil), r.Request.Contacts...)
+
+	pri.Metadata = make(map[string]string, len(r.Metadata)+1)
+	for k, v := range r.Metadata {
+		pri.Metadata[k] = v
+	}
+	if r.ID != "" {
+		pri.Metadata[MetadataReplayOf] = r.ID
+	}
+
+	if !opts.PkupDate.IsZero() {
+		pri.PkupDate = NewDate(opts.PkupDate.Time)
+		pri.ReadyTime = NewTime(onDay(opts.PkupDate.Time, pri.ReadyTime.Time))
+		pri.CloseTime = NewTime(onDay(opts.PkupDate.Time, pri.CloseTime.Time))
+	}
+
+	if opts.Patch != nil {
+		err = opts.Patch(&pri)
+	}
+	return
+}
+
+//onDay returns the time of day of t on day
+func onDay(day, t time.Time) time.Time {
+	y, m, d := day.Date()
+	return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+}
@@ -0,0 +1,83 @@
+package xpo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFailedPickups(t *testing.T) {
+	pickup := testPickup()
+	other := testPickup()
+	other.Remarks = "another pickup"
+	retried := testPickup()
+	retried.Remarks = "requested again"
+	replayFailed := testPickup()
+	replayFailed.Remarks = "replay failed"
+
+	store := &memoryPickupStore{records: []PickupRecord{
+		{ID: "failed", Status: PickupStatusFailed, Request: pickup},
+		{ID: "unknown", Status: PickupStatusUnknown, Request: other},
+		{ID: "requested", Status: PickupStatusRequested, Request: other},
+		{ID: "queued", Status: PickupStatusQueued, Request: other},
+		{ID: "replayed", Status: PickupStatusFailed, Request: other},
+		{ID: "replay", Status: PickupStatusConfirmed, Request: other, Metadata: map[string]string{MetadataReplayOf: "replayed"}},
+		{ID: "failed replay", Status: PickupStatusFailed, Request: replayFailed, Metadata: map[string]string{MetadataReplayOf: "failed"}},
+		{ID: "retried", Status: PickupStatusFailed, Request: retried},
+		{ID: "retried later", Status: PickupStatusConfirmed, Request: retried, Metadata: map[string]string{"orderId": "1"}},
+	}}
+
+	failed, err := FailedPickups(context.Background(), store, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, r := range failed {
+		ids = append(ids, r.ID)
+	}
+	if len(ids) != 2 || ids[0] != "failed" || ids[1] != "failed replay" {
+		t.Errorf("got %q, want only the pickups that failed and weren't booked since", ids)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	fake := &fakeXPO{}
+	store := &memoryPickupStore{}
+	c := fakeClient(fake, store)
+
+	day := NewDate(time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC))
+	records := []PickupRecord{
+		{ID: "failed", Status: PickupStatusFailed, Request: testPickup()},
+		{ID: "unknown", Status: PickupStatusUnknown, Request: testPickup()},
+		{ID: "file", Request: testPickup()},
+	}
+
+	results := c.Replay(context.Background(), records, ReplayOptions{PkupDate: day})
+	if results[0].Err != nil || results[2].Err != nil {
+		t.Fatalf("got %v, %v, want failed and status-less records booked", results[0].Err, results[2].Err)
+	}
+	if !errors.Is(results[1].Err, ErrReplayUnconfirmed) {
+		t.Errorf("got %v, want ErrReplayUnconfirmed for a pickup that may be booked", results[1].Err)
+	}
+	if fake.pickupCount() != 2 {
+		t.Errorf("got %d pickups sent, want 2", fake.pickupCount())
+	}
+
+	saved := store.all()
+	if len(saved) != 2 {
+		t.Fatalf("got %d stored pickups, want 2", len(saved))
+	}
+	if got := saved[0].Metadata[MetadataReplayOf]; got != "failed" {
+		t.Errorf("got %s %q, want the replayed record's id", MetadataReplayOf, got)
+	}
+	if !saved[0].Request.PkupDate.Equal(day.Time) || saved[0].Request.ReadyTime.Hour() != 14 {
+		t.Errorf("got pickup on %v ready at %v, want it moved to %v keeping the ready time", saved[0].Request.PkupDate, saved[0].Request.ReadyTime, day)
+	}
+
+	results = c.Replay(context.Background(), records[1:2], ReplayOptions{PkupDate: day, Confirmed: true})
+	if results[0].Err != nil {
+		t.Errorf("got %v, want a confirmed unknown pickup booked", results[0].Err)
+	}
+}
@@ -0,0 +1,97 @@
+package xpo
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+//Result is the outcome of one item in a batch request
+//Index is the item's position in the batch so failures can be matched back up and retried.
+type Result[T any] struct {
+	Index int
+	Value T
+	Err   error
+}
+
+//OK checks if the item succeeded
+func (r Result[T]) OK() bool {
+	return r.Err == nil
+}
+
+//Results are the outcomes of every item in a batch request, in the same order as the batch
+type Results[T any] []Result[T]
+
+//Succeeded returns the items that succeeded
+func (r Results[T]) Succeeded() (s Results[T]) {
+	for _, v := range r {
+		if v.OK() {
+			s = append(s, v)
+		}
+	}
+	return
+}
+
+//Failed returns the items that failed
+func (r Results[T]) Failed() (f Results[T]) {
+	for _, v := range r {
+		if !v.OK() {
+			f = append(f, v)
+		}
+	}
+	return
+}
+
+//Err returns a *BatchError if any item failed, nil if every item succeeded
+func (r Results[T]) Err() error {
+	e := &BatchError{
+		Total: len(r),
+	}
+	for _, v := range r {
+		if !v.OK() {
+			e.Indexes = append(e.Indexes, v.Index)
+			e.Errs = append(e.Errs, v.Err)
+		}
+	}
+
+	if len(e.Errs) == 0 {
+		return nil
+	}
+	return e
+}
+
+//BatchError is returned when some items in a batch failed
+type BatchError struct {
+	Total   int     //number of items in the batch
+	Indexes []int   //positions of the failed items
+	Errs    []error //why each failed, same order as Indexes
+}
+
+//Error lists every failure
+func (b *BatchError) Error() string {
+	parts := make([]string, 0, len(b.Errs))
+	for i, err := range b.Errs {
+		parts = append(parts, "item "+strconv.Itoa(b.Indexes[i]+1)+": "+err.Error())
+	}
+
+	return "xpo - " + strconv.Itoa(len(b.Errs)) + " of " + strconv.Itoa(b.Total) + " failed: " + strings.Join(parts, "; ")
+}
+
+//RequestPickups requests each pickup, one at a time, and returns the result of each
+//A failed pickup doesn't stop the rest from being requested.  Retry just the failures with Results.Failed, the
+//Index of each result is its position in pris.  If ctx is canceled the remaining pickups fail with ctx.Err().
+func (c *Client) RequestPickups(ctx context.Context, pris []*PickupRqstInfo) (results Results[SuccessfulPickupResponse]) {
+	results = make(Results[SuccessfulPickupResponse], len(pris))
+	for i, pri := range pris {
+		results[i].Index = i
+
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		results[i].Value, results[i].Err = c.RequestPickup(ctx, pri)
+	}
+
+	return
+}
@@ -0,0 +1,510 @@
+package xpo
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//defaultSchedulerInterval is how often the scheduler checks for due pickups if no interval is given
+const defaultSchedulerInterval = 1 * time.Minute
+
+//MetadataStandingPickup is the metadata key set to the standing pickup's id on each pickup booked for it
+const MetadataStandingPickup = "standingPickupId"
+
+//StandingPickup is a pickup booked over and over on a schedule, like every weekday at 14:00
+//Times are 24 hour HH:MM in Location.
+type StandingPickup struct {
+	ID       string         //generated when added to a scheduler if not given
+	Template PickupRqstInfo //the pickup to book, PkupDate, ReadyTime, and CloseTime are set each time it is booked
+	Weekdays []time.Weekday //days to book the pickup on
+	BookAt   string         //time of day to book the pickup with XPO
+	Ready    string         //time freight is ready
+	Close    string         //time the dock closes
+	Location *time.Location //timezone of the shipper, defaults to the client's clock
+
+	//updated by the scheduler each time the pickup is booked
+	LastBooked          Date
+	LastConfirmationNbr ConfirmationNbr
+	LastError           string
+}
+
+//ErrStandingPickupNotFound is returned when canceling a standing pickup that doesn't exist
+var ErrStandingPickupNotFound = errors.New("xpo - standing pickup not found")
+
+//StandingPickups manages standing pickups
+//XPO's API doesn't have standing pickups so Scheduler emulates them by booking a pickup each day, but callers
+//should use this interface so an implementation backed by XPO can be swapped in if XPO ever adds them.
+type StandingPickups interface {
+	Create(ctx context.Context, sp StandingPickup) (StandingPickup, error)
+	List(ctx context.Context) ([]StandingPickup, error)
+	Cancel(ctx context.Context, id string) error
+}
+
+//StandingPickupStore saves standing pickups so they, and the day each was last booked, survive a restart
+//SaveStandingPickup is called when a standing pickup is created and again before each booking is sent to XPO,
+//so a pickup that may have been booked is never booked again for the same day.  SQLitePickupStore implements
+//this.  Set this on SchedulerConfig.Store.
+type StandingPickupStore interface {
+	SaveStandingPickup(ctx context.Context, sp StandingPickup) error
+	DeleteStandingPickup(ctx context.Context, id string) error
+	StandingPickups(ctx context.Context) ([]StandingPickup, error)
+}
+
+//ScheduleResultFunc is called when a standing pickup is booked for a day
+//err is set if XPO rejected the pickup, otherwise response holds the confirmation.
+type ScheduleResultFunc func(sp StandingPickup, response SuccessfulPickupResponse, err error)
+
+//SchedulerConfig holds the settings for a Scheduler
+type SchedulerConfig struct {
+	Interval time.Duration       //how often to check for due pickups, defaults to 1 minute
+	Holidays []Date              //days no standing pickups are booked
+	OnResult ScheduleResultFunc  //called with the result of each booking
+	Store    StandingPickupStore //saves standing pickups, without one they are lost when the process exits
+}
+
+//Scheduler books standing pickups on their schedules, it implements StandingPickups
+//Each standing pickup is booked at most once a day, at or after BookAt and before Close, and never on a
+//holiday.  If the request never reached XPO, the network was down or XPO was in maintenance, the booking is
+//tried again each interval until Close.  Any other failure, like a timeout after the request was sent, is not
+//retried since the pickup may have been booked.  Confirmations are kept on the standing pickup and, like any
+//pickup, saved to the client's PickupStore.  With a Store, standing pickups are loaded from it the first time
+//the scheduler is used.
+type Scheduler struct {
+	client   *Client
+	interval time.Duration
+	holidays map[string]bool //keyed by DateLayout
+	onResult ScheduleResultFunc
+	store    StandingPickupStore
+
+	mu      sync.Mutex
+	pickups map[string]StandingPickup
+	loaded  bool //pickups have been loaded from store
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	//only one booking run at a time so a pickup isn't booked twice
+	bookMu sync.Mutex
+}
+
+//NewScheduler creates a scheduler that books pickups with c
+func NewScheduler(c *Client, cfg SchedulerConfig) *Scheduler {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultSchedulerInterval
+	}
+
+	holidays := make(map[string]bool, len(cfg.Holidays))
+	for _, d := range cfg.Holidays {
+		holidays[d.Format(DateLayout)] = true
+	}
+
+	return &Scheduler{
+		client:   c,
+		interval: cfg.Interval,
+		holidays: holidays,
+		onResult: cfg.OnResult,
+		store:    cfg.Store,
+		pickups:  map[string]StandingPickup{},
+	}
+}
+
+//Create schedules a standing pickup, replacing any with the same id
+//The standing pickup is returned with its generated id.
+func (s *Scheduler) Create(ctx context.Context, sp StandingPickup) (created StandingPickup, err error) {
+	err = sp.validate()
+	if err != nil {
+		err = errors.Wrap(err, "xpo.Create - invalid standing pickup")
+		return
+	}
+
+	if sp.ID == "" {
+		sp.ID, err = newRecordID()
+		if err != nil {
+			err = errors.Wrap(err, "xpo.Create - could not generate id")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = s.load(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.Create - could not load standing pickups")
+		return
+	}
+
+	if s.store != nil {
+		err = s.store.SaveStandingPickup(ctx, sp)
+		if err != nil {
+			err = errors.Wrap(err, "xpo.Create - could not save standing pickup")
+			return
+		}
+	}
+
+	s.pickups[sp.ID] = sp
+
+	created = sp
+	return
+}
+
+//List returns the scheduled standing pickups sorted by id
+func (s *Scheduler) List(ctx context.Context) (pickups []StandingPickup, err error) {
+	pickups, err = s.list(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.List - could not load standing pickups")
+		return
+	}
+
+	return
+}
+
+//Cancel stops booking a standing pickup
+//Pickups already booked with XPO for it are not canceled.
+func (s *Scheduler) Cancel(ctx context.Context, id string) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = s.load(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.Cancel - could not load standing pickups")
+		return
+	}
+
+	if _, ok := s.pickups[id]; !ok {
+		err = ErrStandingPickupNotFound
+		return
+	}
+
+	if s.store != nil {
+		err = s.store.DeleteStandingPickup(ctx, id)
+		if err != nil {
+			err = errors.Wrap(err, "xpo.Cancel - could not delete standing pickup")
+			return
+		}
+	}
+
+	delete(s.pickups, id)
+	return
+}
+
+//load reads the standing pickups from the store the first time it is called
+//s.mu must be held.  Pickups created before loading are kept over stored ones with the same id.
+func (s *Scheduler) load(ctx context.Context) error {
+	if s.loaded || s.store == nil {
+		return nil
+	}
+
+	stored, err := s.store.StandingPickups(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, sp := range stored {
+		if _, exists := s.pickups[sp.ID]; !exists {
+			s.pickups[sp.ID] = sp
+		}
+	}
+
+	s.loaded = true
+	return nil
+}
+
+//list returns the scheduled standing pickups sorted by id
+func (s *Scheduler) list(ctx context.Context) (pickups []StandingPickup, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = s.load(ctx)
+	if err != nil {
+		return
+	}
+
+	for _, sp := range s.pickups {
+		pickups = append(pickups, sp)
+	}
+
+	sort.Slice(pickups, func(i, j int) bool { return pickups[i].ID < pickups[j].ID })
+	return
+}
+
+//Start runs the scheduler in the background until Stop is called
+//Calling Start on a running scheduler does nothing.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	s.cancel = cancel
+	s.done = done
+
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+}
+
+//Stop stops a scheduler started with Start and waits for any booking in progress to finish
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.cancel, s.done = nil, nil
+	s.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	<-done
+}
+
+//Run books due pickups every interval until ctx is canceled
+//Run blocks so start it in its own goroutine, or use Start and Stop.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.BookDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+//BookDue books every standing pickup that is due now
+//The day is saved as booked before the pickup is sent to XPO and only cleared again if the request never
+//reached XPO, so a restart or a response lost after sending doesn't book the same day twice.
+func (s *Scheduler) BookDue(ctx context.Context) {
+	s.bookMu.Lock()
+	defer s.bookMu.Unlock()
+
+	pickups, err := s.list(ctx)
+	if err != nil {
+		s.client.logf("xpo.BookDue - could not load standing pickups: %v", err)
+		return
+	}
+
+	now := s.client.now()
+	for _, sp := range pickups {
+		if ctx.Err() != nil {
+			return
+		}
+
+		day, ok := s.due(sp, now)
+		if !ok {
+			continue
+		}
+
+		previous := sp.LastBooked
+		sp.LastBooked = NewDate(day)
+		if !s.update(ctx, sp) {
+			continue
+		}
+
+		pri := sp.pickup(day)
+		response, err := s.client.RequestPickup(ctx, &pri)
+		if err != nil && isUnreachable(err) {
+			//nothing was sent, try again next interval up until the dock closes
+			s.client.logf("xpo.BookDue - could not reach XPO for standing pickup %s: %v", sp.ID, err)
+			sp.LastBooked = previous
+			s.update(ctx, sp)
+			continue
+		}
+
+		sp.LastConfirmationNbr = response.Data.ConfirmationNbr
+		sp.LastError = ""
+		if err != nil {
+			sp.LastError = err.Error()
+		}
+		s.update(ctx, sp)
+
+		if s.onResult != nil {
+			s.onResult(sp, response, err)
+		}
+	}
+
+	return
+}
+
+//update saves a standing pickup changed while booking, returning false if it couldn't be saved
+//A pickup removed while it was being booked isn't added back.
+func (s *Scheduler) update(ctx context.Context, sp StandingPickup) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.pickups[sp.ID]; !exists {
+		return false
+	}
+
+	if s.store != nil {
+		err := s.store.SaveStandingPickup(ctx, sp)
+		if err != nil {
+			s.client.logf("xpo.BookDue - could not save standing pickup %s: %v", sp.ID, err)
+			return false
+		}
+	}
+
+	s.pickups[sp.ID] = sp
+	return true
+}
+
+//due checks if a standing pickup should be booked now, returning the day to book it for
+func (s *Scheduler) due(sp StandingPickup, now time.Time) (day time.Time, ok bool) {
+	if sp.Location != nil {
+		now = now.In(sp.Location)
+	}
+	day = NewDate(now).Time
+
+	switch {
+	case !containsWeekday(sp.Weekdays, day.Weekday()):
+		return
+	case s.holidays[day.Format(DateLayout)]:
+		return
+	case sp.LastBooked.Format(DateLayout) == day.Format(DateLayout):
+		return
+	case now.Before(atClock(day, sp.BookAt)):
+		return
+	case !now.Before(atClock(day, sp.Close)):
+		return
+	}
+
+	ok = true
+	return
+}
+
+//pickup builds the pickup request to book for a day
+//The template's slices and maps are copied since RequestPickup changes the request.
+func (sp StandingPickup) pickup(day time.Time) (pri PickupRqstInfo) {
+	pri = sp.Template
+	pri.PkupDate = NewDate(day)
+	pri.ReadyTime = NewTime(atClock(day, sp.Ready))
+	pri.CloseTime = NewTime(atClock(day, sp.Close))
+	pri.PkupItem = append([]PkupItem(nil), sp.Template.PkupItem...)
+	pri.Contacts = append([]RoleContact(nil), sp.Template.Contacts...)
+
+	pri.Metadata = make(map[string]string, len(sp.Template.Metadata)+1)
+	for k, v := range sp.Template.Metadata {
+		pri.Metadata[k] = v
+	}
+	pri.Metadata[MetadataStandingPickup] = sp.ID
+	return
+}
+
+//standingPickupJSON is how a standing pickup is json encoded, with the location saved by name
+type standingPickupJSON struct {
+	ID                  string
+	Template            PickupRqstInfo
+	Weekdays            []time.Weekday
+	BookAt              string
+	Ready               string
+	Close               string
+	Location            string `json:",omitempty"`
+	LastBooked          Date
+	LastConfirmationNbr ConfirmationNbr
+	LastError           string
+}
+
+//MarshalJSON encodes a standing pickup for saving, the location is saved by its IANA name
+func (sp StandingPickup) MarshalJSON() ([]byte, error) {
+	j := standingPickupJSON{
+		ID:                  sp.ID,
+		Template:            sp.Template,
+		Weekdays:            sp.Weekdays,
+		BookAt:              sp.BookAt,
+		Ready:               sp.Ready,
+		Close:               sp.Close,
+		LastBooked:          sp.LastBooked,
+		LastConfirmationNbr: sp.LastConfirmationNbr,
+		LastError:           sp.LastError,
+	}
+	if sp.Location != nil {
+		j.Location = sp.Location.String()
+	}
+
+	return json.Marshal(j)
+}
+
+//UnmarshalJSON decodes a standing pickup encoded with MarshalJSON
+func (sp *StandingPickup) UnmarshalJSON(b []byte) (err error) {
+	var j standingPickupJSON
+	err = json.Unmarshal(b, &j)
+	if err != nil {
+		return
+	}
+
+	*sp = StandingPickup{
+		ID:                  j.ID,
+		Template:            j.Template,
+		Weekdays:            j.Weekdays,
+		BookAt:              j.BookAt,
+		Ready:               j.Ready,
+		Close:               j.Close,
+		LastBooked:          j.LastBooked,
+		LastConfirmationNbr: j.LastConfirmationNbr,
+		LastError:           j.LastError,
+	}
+	if j.Location != "" {
+		sp.Location, err = time.LoadLocation(j.Location)
+		if err != nil {
+			err = errors.Wrap(err, "xpo.UnmarshalJSON - invalid standing pickup location")
+			return
+		}
+	}
+
+	return
+}
+
+//validate checks the schedule of a standing pickup
+//The template itself is validated each time it is booked.
+func (sp StandingPickup) validate() error {
+	if len(sp.Weekdays) == 0 {
+		return errors.New("at least one weekday must be given")
+	}
+	if !validClock(sp.BookAt) || !validClock(sp.Ready) || !validClock(sp.Close) {
+		return errors.New("book at, ready, and close times must be given as HH:MM")
+	}
+
+	day := time.Time{}
+	closeTime := atClock(day, sp.Close)
+	if !closeTime.After(atClock(day, sp.Ready)) {
+		return errors.New("close time must be after ready time")
+	}
+	if !closeTime.After(atClock(day, sp.BookAt)) {
+		return errors.New("book at time must be before close time")
+	}
+
+	return nil
+}
+
+//atClock returns the time on day for a 24 hour HH:MM clock time
+//clock must already be valid, see validClock.
+func atClock(day time.Time, clock string) time.Time {
+	t, _ := time.Parse("15:04", clock)
+	y, m, d := day.Date()
+	return time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, day.Location())
+}
+
+//containsWeekday checks if d is in days
+func containsWeekday(days []time.Weekday, d time.Weekday) bool {
+	for _, v := range days {
+		if v == d {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,244 @@
+package xpo
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+//memoryStandingPickupStore is a StandingPickupStore that keeps standing pickups in memory
+type memoryStandingPickupStore struct {
+	mu      sync.Mutex
+	pickups map[string]StandingPickup
+}
+
+//SaveStandingPickup adds or replaces a standing pickup
+func (m *memoryStandingPickupStore) SaveStandingPickup(ctx context.Context, sp StandingPickup) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.pickups == nil {
+		m.pickups = map[string]StandingPickup{}
+	}
+	m.pickups[sp.ID] = sp
+	return nil
+}
+
+//DeleteStandingPickup removes a standing pickup
+func (m *memoryStandingPickupStore) DeleteStandingPickup(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.pickups, id)
+	return nil
+}
+
+//StandingPickups returns every standing pickup
+func (m *memoryStandingPickupStore) StandingPickups(ctx context.Context) (pickups []StandingPickup, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, sp := range m.pickups {
+		pickups = append(pickups, sp)
+	}
+	return
+}
+
+//get returns a saved standing pickup
+func (m *memoryStandingPickupStore) get(id string) StandingPickup {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.pickups[id]
+}
+
+//schedulerClient returns a client that sends its requests to f with its clock at *now
+func schedulerClient(t *testing.T, f *fakeXPO, now *time.Time) *Client {
+	c, err := NewClient(Config{
+		Username:    "user",
+		Password:    "secret",
+		AccessToken: "access",
+		HTTPClient:  &http.Client{Transport: f},
+		Now:         func() time.Time { return *now },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+//testStandingPickup returns a standing pickup booked at 09:00 on Fridays
+func testStandingPickup() StandingPickup {
+	return StandingPickup{
+		ID:       "daily",
+		Template: testPickup(),
+		Weekdays: []time.Weekday{time.Friday},
+		BookAt:   "09:00",
+		Ready:    "14:00",
+		Close:    "17:00",
+		Location: time.UTC,
+	}
+}
+
+func TestSchedulerBookDue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC) //a Friday
+	fake := &fakeXPO{}
+	store := &memoryStandingPickupStore{}
+
+	var results []StandingPickup
+	s := NewScheduler(schedulerClient(t, fake, &now), SchedulerConfig{
+		Store: store,
+		OnResult: func(sp StandingPickup, response SuccessfulPickupResponse, err error) {
+			if err != nil {
+				t.Errorf("got %v, want the pickup booked", err)
+			}
+			results = append(results, sp)
+		},
+	})
+
+	_, err := s.Create(ctx, testStandingPickup())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.BookDue(ctx)
+	if fake.pickupCount() != 0 {
+		t.Fatalf("got %d pickups sent before BookAt, want 0", fake.pickupCount())
+	}
+
+	now = now.Add(2 * time.Hour)
+	s.BookDue(ctx)
+	s.BookDue(ctx)
+	if fake.pickupCount() != 1 || len(results) != 1 {
+		t.Fatalf("got %d pickups sent and %d results, want the day booked once", fake.pickupCount(), len(results))
+	}
+
+	saved := store.get("daily")
+	if saved.LastBooked.Format(DateLayout) != "2026-10-16" || saved.LastConfirmationNbr == "" {
+		t.Errorf("got last booked %v, confirmation %q saved, want today's booking", saved.LastBooked, saved.LastConfirmationNbr)
+	}
+
+	//not a scheduled day
+	now = now.Add(24 * time.Hour)
+	s.BookDue(ctx)
+	if fake.pickupCount() != 1 {
+		t.Errorf("got %d pickups sent, want none on a Saturday", fake.pickupCount()-1)
+	}
+
+	//a new scheduler on the same store doesn't book the day again
+	now = now.Add(-24 * time.Hour)
+	restarted := NewScheduler(schedulerClient(t, fake, &now), SchedulerConfig{Store: store})
+	restarted.BookDue(ctx)
+	if fake.pickupCount() != 1 {
+		t.Errorf("got %d pickups sent after a restart, want none", fake.pickupCount()-1)
+	}
+}
+
+func TestSchedulerRetriesUnreachable(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	fake := &fakeXPO{down: true}
+	store := &memoryStandingPickupStore{}
+	s := NewScheduler(schedulerClient(t, fake, &now), SchedulerConfig{Store: store})
+
+	_, err := s.Create(ctx, testStandingPickup())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.BookDue(ctx)
+	if saved := store.get("daily"); !saved.LastBooked.IsZero() {
+		t.Fatalf("got last booked %v, want it cleared since XPO was never reached", saved.LastBooked)
+	}
+
+	fake.setDown(false)
+	now = now.Add(time.Minute)
+	s.BookDue(ctx)
+	if fake.pickupCount() != 1 {
+		t.Fatalf("got %d pickups sent, want the day booked once XPO is back", fake.pickupCount())
+	}
+
+	//past close it isn't tried at all
+	fake.setDown(true)
+	now = time.Date(2026, 10, 23, 17, 0, 0, 0, time.UTC)
+	s.BookDue(ctx)
+	if saved := store.get("daily"); saved.LastBooked.Format(DateLayout) != "2026-10-16" {
+		t.Errorf("got last booked %v, want nothing tried after close", saved.LastBooked)
+	}
+}
+
+func TestSchedulerNotRetried(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	fake := &fakeXPO{pickupErr: io.ErrUnexpectedEOF}
+	store := &memoryStandingPickupStore{}
+	s := NewScheduler(schedulerClient(t, fake, &now), SchedulerConfig{Store: store})
+
+	_, err := s.Create(ctx, testStandingPickup())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.BookDue(ctx)
+	now = now.Add(time.Minute)
+	s.BookDue(ctx)
+	if fake.pickupCount() != 1 {
+		t.Errorf("got %d pickups sent, want 1 since the first may have been booked", fake.pickupCount())
+	}
+	if saved := store.get("daily"); saved.LastError == "" || saved.LastBooked.Format(DateLayout) != "2026-10-16" {
+		t.Errorf("got %+v, want the day kept as booked with the error", saved)
+	}
+}
+
+func TestSchedulerHoliday(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	fake := &fakeXPO{}
+	s := NewScheduler(schedulerClient(t, fake, &now), SchedulerConfig{
+		Holidays: []Date{NewDate(now)},
+	})
+
+	_, err := s.Create(ctx, testStandingPickup())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.BookDue(ctx)
+	if fake.pickupCount() != 0 {
+		t.Errorf("got %d pickups sent, want none on a holiday", fake.pickupCount())
+	}
+}
+
+func TestSchedulerCancel(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	fake := &fakeXPO{}
+	store := &memoryStandingPickupStore{}
+	s := NewScheduler(schedulerClient(t, fake, &now), SchedulerConfig{Store: store})
+
+	_, err := s.Create(ctx, testStandingPickup())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Cancel(ctx, "daily")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Cancel(ctx, "daily"); err != ErrStandingPickupNotFound {
+		t.Errorf("got %v, want ErrStandingPickupNotFound", err)
+	}
+
+	s.BookDue(ctx)
+	if fake.pickupCount() != 0 {
+		t.Errorf("got %d pickups sent, want none for a canceled standing pickup", fake.pickupCount())
+	}
+	if pickups, _ := store.StandingPickups(ctx); len(pickups) != 0 {
+		t.Errorf("got %d stored standing pickups, want 0", len(pickups))
+	}
+}
@@ -0,0 +1,438 @@
+package xpo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//sqliteSchema creates the tables used by SQLitePickupStore
+//Times are stored as RFC3339 text so this works the same no matter what sqlite driver is used.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS xpo_pickups (
+	id               TEXT PRIMARY KEY,
+	mode             TEXT NOT NULL,
+	created_at       TEXT NOT NULL,
+	request          TEXT NOT NULL,
+	status           TEXT NOT NULL,
+	confirmation_nbr TEXT NOT NULL DEFAULT '',
+	pickup_id        TEXT NOT NULL DEFAULT '',
+	error            TEXT NOT NULL DEFAULT '',
+	updated_at       TEXT NOT NULL,
+	gl_code          TEXT NOT NULL DEFAULT '',
+	cost_center      TEXT NOT NULL DEFAULT '',
+	department       TEXT NOT NULL DEFAULT '',
+	metadata         TEXT NOT NULL DEFAULT '{}'
+);
+
+CREATE TABLE IF NOT EXISTS xpo_pickup_status_changes (
+	id               INTEGER PRIMARY KEY AUTOINCREMENT,
+	record_id        TEXT NOT NULL REFERENCES xpo_pickups(id),
+	status           TEXT NOT NULL,
+	confirmation_nbr TEXT NOT NULL DEFAULT '',
+	pickup_id        TEXT NOT NULL DEFAULT '',
+	error            TEXT NOT NULL DEFAULT '',
+	created_at       TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS xpo_pickup_status_changes_record_id ON xpo_pickup_status_changes(record_id);
+
+CREATE TABLE IF NOT EXISTS xpo_standing_pickups (
+	id         TEXT PRIMARY KEY,
+	pickup     TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
+`
+
+//sqliteMigrations add columns to tables created by older versions of this package
+//sqlite has no ADD COLUMN IF NOT EXISTS so a "duplicate column" error means the migration was already done.
+var sqliteMigrations = []string{
+	`ALTER TABLE xpo_pickups ADD COLUMN gl_code TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE xpo_pickups ADD COLUMN cost_center TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE xpo_pickups ADD COLUMN department TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE xpo_pickups ADD COLUMN metadata TEXT NOT NULL DEFAULT '{}'`,
+}
+
+//SQLitePickupStore is a PickupStore that saves to a sqlite database
+//It is also a StandingPickupStore so a Scheduler's standing pickups can be kept in the same database.
+//The database is opened by the caller so any sqlite driver can be used (mattn/go-sqlite3, modernc.org/sqlite,
+//etc.).  This package does not import a driver.
+type SQLitePickupStore struct {
+	db *sql.DB
+}
+
+//NewSQLitePickupStore creates the tables, if needed, and returns a store using the database
+func NewSQLitePickupStore(ctx context.Context, db *sql.DB) (s *SQLitePickupStore, err error) {
+	_, err = db.ExecContext(ctx, sqliteSchema)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.NewSQLitePickupStore - could not create tables")
+		return
+	}
+
+	for _, m := range sqliteMigrations {
+		_, err = db.ExecContext(ctx, m)
+		if err != nil && !strings.Contains(strings.ToLower(err.Error()), "duplicate column") {
+			err = errors.Wrap(err, "xpo.NewSQLitePickupStore - could not migrate tables")
+			return
+		}
+	}
+	err = nil
+
+	s = &SQLitePickupStore{
+		db: db,
+	}
+	return
+}
+
+//SavePickup saves a new pickup record along with its initial status
+func (s *SQLitePickupStore) SavePickup(ctx context.Context, r PickupRecord) (err error) {
+	request, err := json.Marshal(r.Request)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.SavePickup - could not marshal request")
+		return
+	}
+
+	metadata, err := json.Marshal(r.Metadata)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.SavePickup - could not marshal metadata")
+		return
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.SavePickup - could not begin transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	q := `
+		INSERT INTO xpo_pickups (id, mode, created_at, request, status, confirmation_nbr, pickup_id, error, updated_at, gl_code, cost_center, department, metadata)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = tx.ExecContext(
+		ctx,
+		q,
+		r.ID,
+		r.Mode.String(),
+		formatSQLiteTime(r.CreatedAt),
+		string(request),
+		string(r.Status),
+		r.ConfirmationNbr,
+		r.PickupID,
+		r.Error,
+		formatSQLiteTime(r.UpdatedAt),
+		r.Accounting.GLCode,
+		r.Accounting.CostCenter,
+		r.Accounting.Department,
+		string(metadata),
+	)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.SavePickup - could not insert pickup")
+		return
+	}
+
+	change := PickupStatusChange{
+		Status:          r.Status,
+		Time:            r.CreatedAt,
+		ConfirmationNbr: r.ConfirmationNbr,
+		PickupID:        r.PickupID,
+		Error:           r.Error,
+	}
+	err = insertSQLiteStatusChange(ctx, tx, r.ID, change)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.SavePickup - could not insert status")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		err = errors.Wrap(err, "xpo.SavePickup - could not commit")
+		return
+	}
+
+	return
+}
+
+//UpdatePickup sets the current status of a pickup and saves the change to its history
+func (s *SQLitePickupStore) UpdatePickup(ctx context.Context, id string, change PickupStatusChange) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.UpdatePickup - could not begin transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	q := `
+		UPDATE xpo_pickups
+		SET status = ?, confirmation_nbr = ?, pickup_id = ?, error = ?, updated_at = ?
+		WHERE id = ?
+	`
+	result, err := tx.ExecContext(
+		ctx,
+		q,
+		string(change.Status),
+		change.ConfirmationNbr,
+		change.PickupID,
+		change.Error,
+		formatSQLiteTime(change.Time),
+		id,
+	)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.UpdatePickup - could not update pickup")
+		return
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		err = errors.Wrap(err, "xpo.UpdatePickup - could not check update")
+		return
+	}
+	if n == 0 {
+		err = errors.New("xpo.UpdatePickup - pickup not found")
+		return
+	}
+
+	err = insertSQLiteStatusChange(ctx, tx, id, change)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.UpdatePickup - could not insert status")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		err = errors.Wrap(err, "xpo.UpdatePickup - could not commit")
+		return
+	}
+
+	return
+}
+
+//sqlitePickupColumns are the columns scanned by scanSQLitePickup, in order
+const sqlitePickupColumns = "id, mode, created_at, request, status, confirmation_nbr, pickup_id, error, updated_at, gl_code, cost_center, department, metadata"
+
+//Pickup looks up a saved pickup by its record id
+func (s *SQLitePickupStore) Pickup(ctx context.Context, id string) (r PickupRecord, err error) {
+	q := `
+		SELECT ` + sqlitePickupColumns + `
+		FROM xpo_pickups
+		WHERE id = ?
+	`
+	r, err = scanSQLitePickup(s.db.QueryRowContext(ctx, q, id))
+	if err != nil {
+		err = errors.Wrap(err, "xpo.Pickup - could not look up pickup")
+		return
+	}
+
+	return
+}
+
+//Pickups returns the pickups saved from (inclusive) to (exclusive), oldest first
+//This is by when the pickup was requested, not the pickup date.
+func (s *SQLitePickupStore) Pickups(ctx context.Context, from, to time.Time) (records []PickupRecord, err error) {
+	q := `
+		SELECT ` + sqlitePickupColumns + `
+		FROM xpo_pickups
+		WHERE created_at >= ? AND created_at < ?
+		ORDER BY created_at, id
+	`
+	rows, err := s.db.QueryContext(ctx, q, formatSQLiteTime(from), formatSQLiteTime(to))
+	if err != nil {
+		err = errors.Wrap(err, "xpo.Pickups - could not look up pickups")
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r PickupRecord
+		r, err = scanSQLitePickup(rows)
+		if err != nil {
+			err = errors.Wrap(err, "xpo.Pickups - could not scan pickup")
+			return
+		}
+
+		records = append(records, r)
+	}
+
+	err = rows.Err()
+	return
+}
+
+//scanner is implemented by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+//scanSQLitePickup reads a pickup record selected with sqlitePickupColumns
+func scanSQLitePickup(row scanner) (r PickupRecord, err error) {
+	var mode, createdAt, request, status, updatedAt, metadata string
+	err = row.Scan(
+		&r.ID,
+		&mode,
+		&createdAt,
+		&request,
+		&status,
+		&r.ConfirmationNbr,
+		&r.PickupID,
+		&r.Error,
+		&updatedAt,
+		&r.Accounting.GLCode,
+		&r.Accounting.CostCenter,
+		&r.Accounting.Department,
+		&metadata,
+	)
+	if err != nil {
+		return
+	}
+
+	if mode == ModeProduction.String() {
+		r.Mode = ModeProduction
+	}
+	r.Status = PickupStatus(status)
+	r.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+	r.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updatedAt)
+
+	err = json.Unmarshal([]byte(request), &r.Request)
+	if err != nil {
+		err = errors.Wrap(err, "could not unmarshal request")
+		return
+	}
+
+	err = json.Unmarshal([]byte(metadata), &r.Metadata)
+	if err != nil {
+		err = errors.Wrap(err, "could not unmarshal metadata")
+		return
+	}
+
+	r.Request.Accounting = r.Accounting
+	r.Request.Metadata = r.Metadata
+
+	return
+}
+
+//StatusChanges returns the history of status changes for a saved pickup, oldest first
+func (s *SQLitePickupStore) StatusChanges(ctx context.Context, id string) (changes []PickupStatusChange, err error) {
+	q := `
+		SELECT status, confirmation_nbr, pickup_id, error, created_at
+		FROM xpo_pickup_status_changes
+		WHERE record_id = ?
+		ORDER BY id
+	`
+	rows, err := s.db.QueryContext(ctx, q, id)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.StatusChanges - could not look up status changes")
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c PickupStatusChange
+		var status, createdAt string
+		err = rows.Scan(&status, &c.ConfirmationNbr, &c.PickupID, &c.Error, &createdAt)
+		if err != nil {
+			err = errors.Wrap(err, "xpo.StatusChanges - could not scan status change")
+			return
+		}
+
+		c.Status = PickupStatus(status)
+		c.Time, _ = time.Parse(time.RFC3339Nano, createdAt)
+		changes = append(changes, c)
+	}
+
+	err = rows.Err()
+	return
+}
+
+//insertSQLiteStatusChange saves a status change to the history table
+func insertSQLiteStatusChange(ctx context.Context, tx *sql.Tx, id string, change PickupStatusChange) (err error) {
+	q := `
+		INSERT INTO xpo_pickup_status_changes (record_id, status, confirmation_nbr, pickup_id, error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	_, err = tx.ExecContext(
+		ctx,
+		q,
+		id,
+		string(change.Status),
+		change.ConfirmationNbr,
+		change.PickupID,
+		change.Error,
+		formatSQLiteTime(change.Time),
+	)
+	return
+}
+
+//SaveStandingPickup saves a standing pickup, replacing any with the same id
+func (s *SQLitePickupStore) SaveStandingPickup(ctx context.Context, sp StandingPickup) (err error) {
+	pickup, err := json.Marshal(sp)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.SaveStandingPickup - could not marshal standing pickup")
+		return
+	}
+
+	q := `
+		INSERT OR REPLACE INTO xpo_standing_pickups (id, pickup, updated_at)
+		VALUES (?, ?, ?)
+	`
+	_, err = s.db.ExecContext(ctx, q, sp.ID, string(pickup), formatSQLiteTime(time.Now()))
+	if err != nil {
+		err = errors.Wrap(err, "xpo.SaveStandingPickup - could not save standing pickup")
+		return
+	}
+
+	return
+}
+
+//DeleteStandingPickup removes a saved standing pickup
+func (s *SQLitePickupStore) DeleteStandingPickup(ctx context.Context, id string) (err error) {
+	_, err = s.db.ExecContext(ctx, `DELETE FROM xpo_standing_pickups WHERE id = ?`, id)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.DeleteStandingPickup - could not delete standing pickup")
+		return
+	}
+
+	return
+}
+
+//StandingPickups returns the saved standing pickups sorted by id
+func (s *SQLitePickupStore) StandingPickups(ctx context.Context) (pickups []StandingPickup, err error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT pickup FROM xpo_standing_pickups ORDER BY id`)
+	if err != nil {
+		err = errors.Wrap(err, "xpo.StandingPickups - could not look up standing pickups")
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pickup string
+		err = rows.Scan(&pickup)
+		if err != nil {
+			err = errors.Wrap(err, "xpo.StandingPickups - could not scan standing pickup")
+			return
+		}
+
+		var sp StandingPickup
+		err = json.Unmarshal([]byte(pickup), &sp)
+		if err != nil {
+			err = errors.Wrap(err, "xpo.StandingPickups - could not unmarshal standing pickup")
+			return
+		}
+
+		pickups = append(pickups, sp)
+	}
+
+	err = rows.Err()
+	return
+}
+
+//sqliteTimeLayout is RFC3339 with a fixed number of fractional digits
+//RFC3339Nano drops trailing zeros which makes times within the same second sort wrong as text.
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+//formatSQLiteTime formats a time for storage, always in UTC so stored times sort correctly as text
+func formatSQLiteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeLayout)
+}
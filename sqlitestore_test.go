@@ -0,0 +1,185 @@
+//go:build sqlite
+
+package xpo
+
+//These tests need a sqlite driver, which this package doesn't import, run them with:
+//go get modernc.org/sqlite && go test -tags sqlite
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+//sqliteStore returns a store backed by a new in-memory database
+func sqliteStore(t *testing.T) *SQLitePickupStore {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1) //each connection to :memory: is its own database
+	t.Cleanup(func() { db.Close() })
+
+	s, err := NewSQLitePickupStore(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//a second open migrates an existing database
+	_, err = NewSQLitePickupStore(context.Background(), db)
+	if err != nil {
+		t.Fatalf("got %v, want opening an existing database to work", err)
+	}
+
+	return s
+}
+
+func TestSQLitePickupStore(t *testing.T) {
+	ctx := context.Background()
+	s := sqliteStore(t)
+
+	created := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	pri := testPickup()
+	err := s.SavePickup(ctx, PickupRecord{
+		ID:         "r1",
+		Mode:       ModeProduction,
+		CreatedAt:  created,
+		UpdatedAt:  created,
+		Request:    pri,
+		Accounting: Accounting{GLCode: "6100"},
+		Metadata:   map[string]string{"orderId": "1"},
+		Status:     PickupStatusQueued,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.UpdatePickup(ctx, "r1", PickupStatusChange{
+		Status:          PickupStatusConfirmed,
+		Time:            created.Add(time.Hour),
+		ConfirmationNbr: "CHI000001",
+		PickupID:        "p1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.UpdatePickup(ctx, "missing", PickupStatusChange{Status: PickupStatusFailed}); err == nil {
+		t.Error("got no error updating a pickup that wasn't saved")
+	}
+
+	r, err := s.Pickup(ctx, "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Mode != ModeProduction || r.Status != PickupStatusConfirmed || r.ConfirmationNbr != "CHI000001" || r.PickupID != "p1" {
+		t.Errorf("got %+v, want the confirmed pickup", r)
+	}
+	if !r.CreatedAt.Equal(created) || !r.UpdatedAt.Equal(created.Add(time.Hour)) {
+		t.Errorf("got created %v, updated %v", r.CreatedAt, r.UpdatedAt)
+	}
+	if r.Accounting.GLCode != "6100" || r.Metadata["orderId"] != "1" || r.Request.Metadata["orderId"] != "1" {
+		t.Errorf("got accounting %+v, metadata %v, want them saved and copied to the request", r.Accounting, r.Metadata)
+	}
+	if r.Request.Shipper.Name != pri.Shipper.Name || len(r.Request.PkupItem) != len(pri.PkupItem) {
+		t.Errorf("got request %+v, want the saved request", r.Request)
+	}
+
+	changes, err := s.StatusChanges(ctx, "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].Status != PickupStatusQueued || changes[1].Status != PickupStatusConfirmed {
+		t.Errorf("got %+v, want queued then confirmed", changes)
+	}
+
+	records, err := s.Pickups(ctx, created, created.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Errorf("got %d pickups in range, want 1", len(records))
+	}
+	if records, _ = s.Pickups(ctx, created.Add(time.Minute), created.Add(time.Hour)); len(records) != 0 {
+		t.Errorf("got %d pickups out of range, want 0", len(records))
+	}
+}
+
+func TestSQLitePickupStoreWithClient(t *testing.T) {
+	ctx := context.Background()
+	s := sqliteStore(t)
+	fake := &fakeXPO{down: true}
+	q := &MemoryPickupQueue{}
+	o := NewOutbox(fakeClient(fake, s), q, OutboxConfig{})
+
+	pri := testPickup()
+	_, queued, err := o.Submit(ctx, &pri)
+	if err != nil || !queued {
+		t.Fatalf("got queued %t, %v, want the pickup queued", queued, err)
+	}
+
+	fake.setDown(false)
+	err = o.Flush(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := s.Pickups(ctx, time.Time{}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Status != PickupStatusConfirmed {
+		t.Fatalf("got %+v, want one confirmed record", records)
+	}
+
+	changes, _ := s.StatusChanges(ctx, records[0].ID)
+	if len(changes) != 2 || changes[0].Status != PickupStatusQueued {
+		t.Errorf("got %+v, want queued then confirmed", changes)
+	}
+}
+
+func TestSQLiteStandingPickups(t *testing.T) {
+	ctx := context.Background()
+	s := sqliteStore(t)
+
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	sp := testStandingPickup()
+	sp.Location = chicago
+	sp.LastBooked = NewDate(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC))
+	err = s.SaveStandingPickup(ctx, sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sp.LastConfirmationNbr = "CHI000001"
+	err = s.SaveStandingPickup(ctx, sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pickups, err := s.StandingPickups(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pickups) != 1 {
+		t.Fatalf("got %d standing pickups, want 1", len(pickups))
+	}
+	got := pickups[0]
+	if got.Location.String() != "America/Chicago" || got.LastConfirmationNbr != "CHI000001" || got.LastBooked.Format(DateLayout) != "2026-10-16" {
+		t.Errorf("got %+v, want the saved standing pickup", got)
+	}
+
+	err = s.DeleteStandingPickup(ctx, sp.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pickups, _ = s.StandingPickups(ctx); len(pickups) != 0 {
+		t.Errorf("got %d standing pickups after deleting, want 0", len(pickups))
+	}
+}
@@ -0,0 +1,145 @@
+package xpo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//PickupStatus is where a pickup request is at
+type PickupStatus string
+
+//pickup statuses
+//A pickup is saved as requested before it is sent to XPO and then moves to confirmed, failed, or unknown.
+//Failed means the pickup certainly wasn't booked: XPO rejected it, it was invalid, or it never reached XPO.
+//Unknown means the request was sent but no answer came back, like a timeout or a dropped connection, so XPO
+//may have booked it; check with XPO before requesting it again.  A pickup sent through an Outbox while XPO
+//can't be reached is queued until the Outbox sends it, the same record then moves on from queued.
+const (
+	PickupStatusRequested PickupStatus = "requested"
+	PickupStatusQueued    PickupStatus = "queued"
+	PickupStatusConfirmed PickupStatus = "confirmed"
+	PickupStatusFailed    PickupStatus = "failed"
+	PickupStatusUnknown   PickupStatus = "unknown"
+)
+
+//PickupRecord is a pickup request as saved in a PickupStore
+type PickupRecord struct {
+	ID        string //generated by the client, not the XPO pickup id
+	Mode      Mode
+	CreatedAt time.Time
+	Request   PickupRqstInfo
+
+	//copied from the request since they are not part of what is sent to XPO
+	Accounting Accounting
+	Metadata   map[string]string
+
+	//updated as the status changes
+	Status          PickupStatus
+	ConfirmationNbr ConfirmationNbr
+	PickupID        string //XPO's pickup id
+	Error           string
+	UpdatedAt       time.Time
+}
+
+//PickupStatusChange is a change in status of a saved pickup
+type PickupStatusChange struct {
+	Status          PickupStatus
+	Time            time.Time
+	ConfirmationNbr ConfirmationNbr
+	PickupID        string
+	Error           string
+}
+
+//PickupStore saves a durable history of pickup requests made by a client
+//SavePickup is called before a request is sent to XPO; if it fails the pickup is not requested so nothing
+//gets booked that wasn't recorded.  UpdatePickup is called with each status change after that.  Set this
+//on Config.PickupStore.
+type PickupStore interface {
+	SavePickup(ctx context.Context, r PickupRecord) error
+	UpdatePickup(ctx context.Context, id string, change PickupStatusChange) error
+}
+
+//newRecordID generates a random id for a stored record
+func newRecordID() (id string, err error) {
+	b := make([]byte, 16)
+	_, err = rand.Read(b)
+	if err != nil {
+		return
+	}
+
+	id = hex.EncodeToString(b)
+	return
+}
+
+//savePickup saves a new pickup to the client's store as requested
+func (c *Client) savePickup(ctx context.Context, pri *PickupRqstInfo) (id string, err error) {
+	id, err = newRecordID()
+	if err != nil {
+		return
+	}
+
+	now := c.now()
+	r := PickupRecord{
+		ID:         id,
+		Mode:       c.mode,
+		CreatedAt:  now,
+		Request:    *pri,
+		Accounting: pri.Accounting,
+		Metadata:   pri.Metadata,
+		Status:     PickupStatusRequested,
+		UpdatedAt:  now,
+	}
+	err = c.pickupStore.SavePickup(ctx, r)
+	return
+}
+
+//updatePickup saves the result of a pickup request to the client's store
+//queued is set when the pickup will be sent again if XPO couldn't be reached.  The pickup has already been
+//requested at this point so a failure to save is logged instead of returned.
+func (c *Client) updatePickup(ctx context.Context, id string, response SuccessfulPickupResponse, requestErr error, queued bool) {
+	change := PickupStatusChange{
+		Status:          PickupStatusConfirmed,
+		Time:            c.now(),
+		ConfirmationNbr: response.Data.ConfirmationNbr,
+		PickupID:        response.Data.PickupID,
+	}
+	switch {
+	case requestErr != nil && queued && isUnreachable(requestErr):
+		change.Status = PickupStatusQueued
+		change.Error = requestErr.Error()
+	case requestErr != nil && rejected(requestErr):
+		change.Status = PickupStatusFailed
+		change.Error = requestErr.Error()
+	case requestErr != nil:
+		change.Status = PickupStatusUnknown
+		change.Error = requestErr.Error()
+	}
+
+	err := c.pickupStore.UpdatePickup(ctx, id, change)
+	if err != nil {
+		c.logf("xpo.RequestPickup - could not update stored pickup %s: %v", id, err)
+	}
+
+	c.publish(ctx, Event{
+		Type:            EventStatusChanged,
+		RecordID:        id,
+		ConfirmationNbr: change.ConfirmationNbr,
+		PickupID:        change.PickupID,
+		Status:          string(change.Status),
+		Error:           change.Error,
+		Metadata:        response.Metadata,
+	})
+	return
+}
+
+//rejected checks if a pickup request certainly wasn't booked
+//That is XPO answering with a fault, the pickup failing validation, or the request never reaching XPO.
+func rejected(err error) bool {
+	var apiErr *APIError
+	var validationErr *ValidationError
+	return errors.As(err, &apiErr) || errors.As(err, &validationErr) || isUnreachable(err)
+}
@@ -0,0 +1,40 @@
+package xpo
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestRequestPickupStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		fake   *fakeXPO
+		status PickupStatus
+	}{
+		{name: "booked", fake: &fakeXPO{}, status: PickupStatusConfirmed},
+		{name: "fault", fake: &fakeXPO{pickupFault: "400"}, status: PickupStatusFailed},
+		{name: "never sent", fake: &fakeXPO{pickupErr: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}, status: PickupStatusFailed},
+		{name: "connection dropped after sending", fake: &fakeXPO{pickupErr: io.ErrUnexpectedEOF}, status: PickupStatusUnknown},
+		{name: "timed out after sending", fake: &fakeXPO{pickupErr: context.DeadlineExceeded}, status: PickupStatusUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryPickupStore{}
+			pri := testPickup()
+			fakeClient(tt.fake, store).RequestPickup(context.Background(), &pri)
+
+			records := store.all()
+			if len(records) != 1 {
+				t.Fatalf("got %d records, want 1", len(records))
+			}
+			if records[0].Status != tt.status {
+				t.Errorf("got status %s, want %s (%s)", records[0].Status, tt.status, records[0].Error)
+			}
+		})
+	}
+}
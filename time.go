@@ -0,0 +1,151 @@
+package xpo
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//layouts XPO uses for dates and times
+//XPO does not send or expect a timezone, times are local to the pickup location.
+const (
+	TimeLayout = "2006-01-02T15:04:05"
+	DateLayout = "2006-01-02"
+)
+
+//parseLayouts are the formats accepted when unmarshaling a Time or Date
+var parseLayouts = []string{
+	TimeLayout,
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	DateLayout,
+}
+
+//Time is a date and time in XPO's format (YYYY-MM-DDTHH:MM:SS)
+//The time is sent as is, in whatever location it was created in, so build it in the pickup location's
+//local time.  Unmarshaling also handles unix timestamps, in seconds or milliseconds, sent as a number or a
+//string, which is what XPO does for transactionTimestamp.
+type Time struct {
+	time.Time
+}
+
+//Date is a date in XPO's format
+//XPO sends and expects dates with a midnight time attached (YYYY-MM-DDT00:00:00).
+type Date struct {
+	time.Time
+}
+
+//NewTime returns a Time
+func NewTime(t time.Time) Time {
+	return Time{t}
+}
+
+//NewDate returns a Date for the day of t
+func NewDate(t time.Time) Date {
+	y, m, d := t.Date()
+	return Date{time.Date(y, m, d, 0, 0, 0, 0, t.Location())}
+}
+
+//ParseTime parses a time given as a string in XPO's format, RFC3339, or a unix timestamp
+//Use this to convert code written when ReadyTime and CloseTime were strings.
+func ParseTime(s string) (t Time, err error) {
+	t.Time, err = parseTime([]byte(s))
+	return
+}
+
+//ParseDate parses a date given as a string in XPO's format, with or without the time, the time is dropped
+//Use this to convert code written when PkupDate was a string.
+func ParseDate(s string) (d Date, err error) {
+	err = d.UnmarshalJSON([]byte(s))
+	return
+}
+
+//String formats the time in XPO's format
+func (t Time) String() string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(TimeLayout)
+}
+
+//MarshalJSON formats the time in XPO's format
+//A zero time is sent as an empty string.
+func (t Time) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(t.String())), nil
+}
+
+//UnmarshalJSON parses XPO's time format, RFC3339, or a unix timestamp
+func (t *Time) UnmarshalJSON(b []byte) (err error) {
+	t.Time, err = parseTime(b)
+	return
+}
+
+//String formats the date in XPO's format
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+
+	return d.Format(DateLayout) + "T00:00:00"
+}
+
+//MarshalJSON formats the date in XPO's format
+//A zero date is sent as an empty string.
+func (d Date) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+//UnmarshalJSON parses a date with or without a time, the time is dropped
+func (d *Date) UnmarshalJSON(b []byte) (err error) {
+	t, err := parseTime(b)
+	if err != nil {
+		return
+	}
+
+	if t.IsZero() {
+		d.Time = time.Time{}
+		return
+	}
+
+	*d = NewDate(t)
+	return
+}
+
+//parseTime parses any of the time formats XPO sends
+func parseTime(b []byte) (t time.Time, err error) {
+	b = bytes.TrimSpace(b)
+	if bytes.Equal(b, []byte("null")) {
+		return
+	}
+
+	s := strings.Trim(string(b), `"`)
+	if s == "" {
+		return
+	}
+
+	//unix timestamp, either a json number or a string of digits
+	if n, convErr := strconv.ParseInt(s, 10, 64); convErr == nil {
+		//anything this big is in milliseconds, seconds won't get here until the year 33658
+		if n >= 1e12 {
+			t = time.Unix(0, n*int64(time.Millisecond)).UTC()
+			return
+		}
+
+		t = time.Unix(n, 0).UTC()
+		return
+	}
+
+	for _, layout := range parseLayouts {
+		t, err = time.Parse(layout, s)
+		if err == nil {
+			return
+		}
+	}
+
+	err = errors.New("xpo - could not parse time " + s)
+	return
+}
@@ -0,0 +1,172 @@
+package xpo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/coreymgilmore/xpologistics/transport"
+)
+
+//token lifetimes
+//XPO says bearer tokens last 12 hours, this is used if the token response doesn't say.  Tokens are renewed a bit
+//before they expire so a request never goes out with a token that expires while in flight.
+const (
+	defaultTokenLifetime = 12 * time.Hour
+	tokenRefreshBefore   = 5 * time.Minute
+	tokenRetryInterval   = 1 * time.Minute
+)
+
+//StoredToken is a bearer token saved in a TokenStore
+type StoredToken struct {
+	BearerToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+}
+
+//fresh checks if the token can still be used at now
+func (t StoredToken) fresh(now time.Time) bool {
+	return t.BearerToken != "" && now.Before(t.refreshAt())
+}
+
+//refreshAt returns when the token should be renewed
+func (t StoredToken) refreshAt() time.Time {
+	return t.ExpiresAt.Add(-tokenRefreshBefore)
+}
+
+//TokenStore saves bearer tokens so they can be reused until they expire
+//Tokens are keyed by tenant, username, mode, and XPO host so one store can be shared by many clients.  Set
+//this on Config.TokenStore to share tokens between processes, the default keeps them in memory.
+type TokenStore interface {
+	Token(ctx context.Context, key string) (t StoredToken, ok bool, err error)
+	SaveToken(ctx context.Context, key string, t StoredToken) error
+}
+
+//MemoryTokenStore is a TokenStore that keeps tokens in memory
+type MemoryTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]StoredToken
+}
+
+//NewMemoryTokenStore returns an empty in memory token store
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{
+		tokens: map[string]StoredToken{},
+	}
+}
+
+//Token returns the token saved for key
+func (s *MemoryTokenStore) Token(ctx context.Context, key string) (t StoredToken, ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok = s.tokens[key]
+	return
+}
+
+//SaveToken saves the token for key, replacing any token already saved
+func (s *MemoryTokenStore) SaveToken(ctx context.Context, key string, t StoredToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[key] = t
+	return nil
+}
+
+//tokenKey is the key the client's tokens are saved under
+//The mode and the host tokens are retrieved from are part of the key so a store shared by test and production
+//clients, or by clients pointed at a simulator, never hands one's token to the other.
+func (c *Client) tokenKey(creds Credentials) string {
+	return c.tenant + "/" + creds.Username + "/" + c.mode.String() + "/" + c.url(xpoTokenURL)
+}
+
+//cachedToken returns the saved token, if there is one
+//A store that can't be read is logged and treated as empty so requests still go through.
+func (c *Client) cachedToken(ctx context.Context, creds Credentials) (t StoredToken, ok bool) {
+	t, ok, err := c.tokenStore.Token(ctx, c.tokenKey(creds))
+	if err != nil {
+		c.logf("xpo - could not read token store: %v", err)
+		return StoredToken{}, false
+	}
+
+	return
+}
+
+//saveToken saves a token retrieved at now
+func (c *Client) saveToken(ctx context.Context, creds Credentials, token transport.TokenResponse, now time.Time) {
+	lifetime := time.Duration(token.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = defaultTokenLifetime
+	}
+
+	t := StoredToken{
+		BearerToken:  token.BearerToken,
+		RefreshToken: token.RefreshToken,
+		ExpiresAt:    now.Add(lifetime),
+	}
+	err := c.tokenStore.SaveToken(ctx, c.tokenKey(creds), t)
+	if err != nil {
+		c.logf("xpo - could not save token: %v", err)
+	}
+	return
+}
+
+//renewToken drops the cached token, after XPO refused it, and gets a new one
+func (c *Client) renewToken(ctx context.Context) (bearerToken string, err error) {
+	creds, err := c.getCredentials(ctx)
+	if err != nil {
+		return
+	}
+
+	//cleared first so the refused token isn't used again if a new one can't be retrieved
+	err = c.tokenStore.SaveToken(ctx, c.tokenKey(creds), StoredToken{})
+	if err != nil {
+		c.logf("xpo - could not clear token: %v", err)
+	}
+
+	return c.fetchToken(ctx, creds)
+}
+
+//RunTokenRefresher keeps the client's bearer token fresh until ctx is canceled
+//Without this the first request after a token expires has to wait for a new token.  A token is retrieved right
+//away and then renewed shortly before each one expires.  If XPO can't be reached the renewal is tried again
+//every minute, requests made in the meantime get a token themselves as usual.  RunTokenRefresher blocks so
+//start it in its own goroutine.
+func (c *Client) RunTokenRefresher(ctx context.Context) {
+	for {
+		wait := tokenRetryInterval
+		creds, err := c.getCredentials(ctx)
+		switch {
+		case err != nil:
+			c.logf("xpo.RunTokenRefresher - %v", err)
+		case c.refreshToken(ctx, creds):
+			if t, ok := c.cachedToken(ctx, creds); ok && t.fresh(c.now()) {
+				wait = t.refreshAt().Sub(c.now())
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+//refreshToken gets a new token if the cached one is due for renewal
+//false is returned if a new token was needed but couldn't be retrieved.
+func (c *Client) refreshToken(ctx context.Context, creds Credentials) bool {
+	if t, ok := c.cachedToken(ctx, creds); ok && t.fresh(c.now()) {
+		return true
+	}
+
+	_, err := c.fetchToken(ctx, creds)
+	if err != nil {
+		c.logf("xpo.RunTokenRefresher - could not refresh token: %v", err)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,45 @@
+package xpo
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestTokenKeySeparatesModes(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryTokenStore()
+	client := func(mode Mode, baseURL string) *Client {
+		c, err := NewClient(Config{
+			Username:        "user",
+			Password:        "secret",
+			AccessToken:     "access",
+			Mode:            mode,
+			AllowProduction: true,
+			BaseURL:         baseURL,
+			TokenStore:      store,
+			HTTPClient:      &http.Client{Transport: &fakeXPO{}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	test := client(ModeTest, "")
+	creds, _ := test.getCredentials(ctx)
+	_, err := test.getRequestToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := client(ModeProduction, "").cachedToken(ctx, creds); ok {
+		t.Error("production client got the test client's token")
+	}
+	if _, ok := client(ModeTest, "http://localhost:8080").cachedToken(ctx, creds); ok {
+		t.Error("client for another host got the test client's token")
+	}
+	if _, ok := client(ModeTest, "").cachedToken(ctx, creds); !ok {
+		t.Error("client with the same settings didn't get the saved token")
+	}
+}
@@ -0,0 +1,196 @@
+package xpo
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//xpoTrackingURL is where shipment status is retrieved from
+//PROs are given in the referenceNumbers query parameter, comma separated.
+const xpoTrackingURL = xpoBaseURL + "/tracking/1.0/shipments/shipment-status-details"
+
+//maxTrackingPROs is the most PROs sent in one tracking request, more are split into multiple requests
+const maxTrackingPROs = 25
+
+//TrackingResponse is the data returned when tracking shipments
+type TrackingResponse struct {
+	Code                 string       `json:"code"`
+	TransactionTimestamp Time         `json:"transactionTimestamp"` //unix timestamp
+	Data                 TrackingData `json:"data"`
+}
+
+//TrackingData holds the status of each shipment tracked
+type TrackingData struct {
+	Shipments []Shipment `json:"shipmentStatusDtls"`
+}
+
+//Shipment is the current status and history of one shipment
+type Shipment struct {
+	ReferenceNbr          string          `json:"referenceNbr"` //the number that was tracked
+	PRO                   PRO             `json:"proNbr"`
+	Status                ShipmentStatus  `json:"shipmentStatus"`
+	EstimatedDeliveryDate Date            `json:"estimatedDeliveryDate"`
+	DeliveryDate          Date            `json:"actualDeliveryDate"` //zero until delivered
+	Origin                Terminal        `json:"originTerminal"`
+	Destination           Terminal        `json:"destinationTerminal"`
+	Events                []TrackingEvent `json:"trackingEvents"` //sorted oldest first by TrackByPRO
+}
+
+//ShipmentStatus is where a shipment is at
+type ShipmentStatus struct {
+	Code        string `json:"statusCd"`   //ex: PICKED_UP, IN_TRANSIT, OUT_FOR_DELIVERY, DELIVERED
+	Description string `json:"statusDesc"` //readable status
+}
+
+//Terminal is an XPO service center
+type Terminal struct {
+	SicCd   string `json:"sicCd"` //XPO's terminal code
+	City    string `json:"cityName"`
+	StateCd string `json:"stateCd"`
+}
+
+//String formats the terminal as city, state (code)
+func (t Terminal) String() string {
+	s := t.City
+	if t.StateCd != "" {
+		s += ", " + t.StateCd
+	}
+	if t.SicCd != "" {
+		s += " (" + t.SicCd + ")"
+	}
+	return strings.TrimSpace(s)
+}
+
+//TrackingEvent is one status change or scan of a shipment
+type TrackingEvent struct {
+	Time        Time     `json:"eventDateTime"` //local to the terminal
+	Code        string   `json:"eventCd"`
+	Description string   `json:"eventDesc"`
+	Terminal    Terminal `json:"terminal"`
+}
+
+//ErrShipmentNotFound is the error for a PRO that XPO has no shipment for
+var ErrShipmentNotFound = errors.New("xpo - shipment not found")
+
+//TrackByPRO gets the current status and history of shipments by PRO number
+//PROs may be given as 9 or 11 digits, see ParsePRO.  Many PROs are tracked in as few requests as possible, so
+//poll a batch of shipments with one call instead of one call per PRO.  The bearer token is reused between
+//calls until it expires, like for pickup requests.
+//
+//There is one result per PRO given, the Index of each is its position in proNumbers.  An invalid PRO fails
+//with the parse error, a PRO XPO has no shipment for fails with ErrShipmentNotFound, and if a request fails
+//every PRO in it fails with that error.  A failure doesn't stop the rest from being tracked.  An
+//EventStatusChanged event is published the first time this client sees a shipment and whenever its status
+//changes after that.
+func (c *Client) TrackByPRO(ctx context.Context, proNumbers ...string) (results Results[Shipment]) {
+	results = make(Results[Shipment], len(proNumbers))
+
+	//indexes of each valid PRO, a PRO given more than once is only sent once
+	indexes := map[string][]int{}
+	var pros []string
+	for i, s := range proNumbers {
+		results[i].Index = i
+
+		p, err := ParsePRO(s)
+		if err != nil {
+			results[i].Err = c.wrapMode(errors.Wrap(err, "xpo.TrackByPRO - invalid PRO number"))
+			continue
+		}
+
+		pro := p.String()
+		if _, ok := indexes[pro]; !ok {
+			pros = append(pros, pro)
+		}
+		indexes[pro] = append(indexes[pro], i)
+	}
+
+	for len(pros) > 0 {
+		n := len(pros)
+		if n > maxTrackingPROs {
+			n = maxTrackingPROs
+		}
+		batch := pros[:n]
+		pros = pros[n:]
+
+		r, err := Call[struct{}, TrackingResponse](ctx, c, c.trackingEndpoint(batch), struct{}{})
+		if err != nil {
+			for _, pro := range batch {
+				for _, i := range indexes[pro] {
+					results[i].Err = err
+				}
+			}
+			continue
+		}
+
+		found := make(map[string]Shipment, len(r.Data.Shipments))
+		for _, sh := range r.Data.Shipments {
+			sort.SliceStable(sh.Events, func(i, j int) bool { return sh.Events[i].Time.Before(sh.Events[j].Time.Time) })
+			found[sh.normalizedPRO()] = sh
+		}
+
+		for _, pro := range batch {
+			sh, ok := found[pro]
+			if !ok {
+				for _, i := range indexes[pro] {
+					results[i].Err = c.wrapMode(errors.Wrap(ErrShipmentNotFound, "xpo.TrackByPRO - "+pro))
+				}
+				continue
+			}
+
+			c.trackStatus(ctx, sh)
+			for _, i := range indexes[pro] {
+				results[i].Value = sh
+			}
+		}
+	}
+
+	return
+}
+
+//trackStatus publishes an EventStatusChanged event if a shipment's status is new to this client
+func (c *Client) trackStatus(ctx context.Context, sh Shipment) {
+	pro := sh.normalizedPRO()
+
+	c.trackedMu.Lock()
+	changed := c.tracked[pro] != sh.Status.Code
+	c.tracked[pro] = sh.Status.Code
+	c.trackedMu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	c.publish(ctx, Event{
+		Type:   EventStatusChanged,
+		PRO:    pro,
+		Status: sh.Status.Code,
+	})
+	return
+}
+
+//normalizedPRO returns the shipment's PRO in the format ParsePRO returns so it can be matched to the PRO tracked
+//XPO may send the PRO in its 11 digit format, the reference number that was tracked is used if the PRO is
+//missing.
+func (sh Shipment) normalizedPRO() string {
+	for _, s := range []string{string(sh.PRO), sh.ReferenceNbr} {
+		if p, err := ParsePRO(s); err == nil {
+			return p.String()
+		}
+	}
+
+	return sh.PRO.String()
+}
+
+//trackingEndpoint returns the tracking endpoint for a batch of PROs
+func (c *Client) trackingEndpoint(pros []string) Endpoint {
+	return Endpoint{
+		Name:   EndpointTracking,
+		Method: http.MethodGet,
+		URL:    c.url(xpoTrackingURL) + "?referenceNumbers=" + url.QueryEscape(strings.Join(pros, ",")),
+	}
+}
@@ -0,0 +1,179 @@
+package xpo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParsePRO(t *testing.T) {
+	tests := []struct {
+		in   string
+		want PRO
+		ok   bool
+	}{
+		{"123-456789", "123-456789", true},
+		{"123456789", "123-456789", true},
+		{" 123 456789 ", "123-456789", true},
+		{"01230456789", "123-456789", true},
+		{"11230456789", "", false},
+		{"12345678", "", false},
+		{"1234567890", "", false},
+		{"12-3456789", "", false},
+		{"ABC-456789", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePRO(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParsePRO(%q) error = %v, want ok %t", tt.in, err, tt.ok)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePRO(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizedPRO(t *testing.T) {
+	tests := []struct {
+		name string
+		sh   Shipment
+		want string
+	}{
+		{"9 digits", Shipment{PRO: "123-456789"}, "123-456789"},
+		{"9 digits without hyphen", Shipment{PRO: "123456789"}, "123-456789"},
+		{"11 digits", Shipment{PRO: "01230456789"}, "123-456789"},
+		{"missing PRO uses reference", Shipment{ReferenceNbr: "123456789"}, "123-456789"},
+		{"invalid PRO uses reference", Shipment{PRO: "n/a", ReferenceNbr: "01230456789"}, "123-456789"},
+		{"nothing valid", Shipment{PRO: "n/a"}, "n/a"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.sh.normalizedPRO(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+//trackingServer is an httptest server answering XPO's token and tracking endpoints with a status set per PRO
+type trackingServer struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	status map[string]string //keyed by 9 digit PRO without the hyphen
+}
+
+func newTrackingServer(t *testing.T) *trackingServer {
+	s := &trackingServer{status: map[string]string{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/token" {
+			w.Write([]byte(`{"access_token":"bearer","expires_in":43200}`))
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		var response TrackingResponse
+		response.Code = "200"
+		for _, ref := range strings.Split(r.URL.Query().Get("referenceNumbers"), ",") {
+			pro := strings.Replace(ref, "-", "", 1)
+			status, ok := s.status[pro]
+			if !ok {
+				continue
+			}
+
+			//XPO sends the 11 digit PRO
+			response.Data.Shipments = append(response.Data.Shipments, Shipment{
+				ReferenceNbr: ref,
+				PRO:          PRO("0" + pro[:3] + "0" + pro[3:]),
+				Status:       ShipmentStatus{Code: status},
+			})
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+//setStatus sets the status returned for a PRO
+func (s *trackingServer) setStatus(pro, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status[pro] = status
+}
+
+func TestTrackByPRO(t *testing.T) {
+	srv := newTrackingServer(t)
+	srv.setStatus("123456789", "PICKED_UP")
+
+	var mu sync.Mutex
+	var events []Event
+	c, err := NewClient(Config{
+		Username:    "user",
+		Password:    "secret",
+		AccessToken: "access",
+		BaseURL:     srv.URL,
+		ReadOnly:    true,
+		EventPublisher: EventPublisherFunc(func(ctx context.Context, e Event) error {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, e)
+			return nil
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eventCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(events)
+	}
+
+	ctx := context.Background()
+	results := c.TrackByPRO(ctx, "123-456789", "bad", "987-654321", "01230456789")
+	if len(results) != 4 {
+		t.Fatalf("got %d results, want one per PRO", len(results))
+	}
+	if results[0].Err != nil || results[0].Value.Status.Code != "PICKED_UP" {
+		t.Errorf("got %+v, %v, want the shipment", results[0].Value, results[0].Err)
+	}
+	if results[1].Err == nil {
+		t.Error("got no error for an invalid PRO")
+	}
+	if !errors.Is(results[2].Err, ErrShipmentNotFound) {
+		t.Errorf("got %v, want ErrShipmentNotFound", results[2].Err)
+	}
+	if results[3].Err != nil || results[3].Value.Status.Code != "PICKED_UP" {
+		t.Errorf("got %+v, %v, want the same shipment for the 11 digit PRO", results[3].Value, results[3].Err)
+	}
+	if n := eventCount(); n != 1 {
+		t.Fatalf("got %d events, want 1 for the first time the shipment was seen", n)
+	}
+
+	//same status again, nothing is published
+	c.TrackByPRO(ctx, "123456789")
+	if n := eventCount(); n != 1 {
+		t.Errorf("got %d events, want none for an unchanged status", n-1)
+	}
+
+	srv.setStatus("123456789", "IN_TRANSIT")
+	c.TrackByPRO(ctx, "123456789")
+	if n := eventCount(); n != 2 {
+		t.Fatalf("got %d events, want one for the status change", n-1)
+	}
+	if e := events[1]; e.Type != EventStatusChanged || e.PRO != "123-456789" || e.Status != "IN_TRANSIT" {
+		t.Errorf("got event %+v", e)
+	}
+}
@@ -0,0 +1,127 @@
+package transport
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+//failover defaults
+const (
+	DefaultFailoverThreshold = 3
+	DefaultFailbackAfter     = 5 * time.Minute
+)
+
+//FailoverTransport is an http.RoundTripper that sends requests to a fallback base url when the primary is failing
+//After Threshold requests in a row fail, requests go to Fallback instead.  Once FailbackAfter has passed the
+//primary is tried again, if it works requests stay there, if not they go back to the fallback for another
+//FailbackAfter.  A request fails when it can't be made or gets a 5xx response.
+//
+//The fallback is usually a proxy or cache in front of XPO or an alternate XPO host.  Requests keep their path
+//and query, only the scheme and host are swapped, and the fallback's path, if any, is added to the front.  The
+//Authorization header is removed so the primary's bearer token isn't handed to another host, unless
+//ForwardCredentials is set.  Token requests, see EndpointToken, always go to the primary since they carry the
+//access token and password.
+type FailoverTransport struct {
+	Next          http.RoundTripper //defaults to http.DefaultTransport
+	Fallback      *url.URL          //required
+	Threshold     int               //failures in a row before failing over, defaults to DefaultFailoverThreshold
+	FailbackAfter time.Duration     //how long to use the fallback before trying the primary, defaults to DefaultFailbackAfter
+
+	//ForwardCredentials sends the Authorization header to the fallback too, only set this if the fallback is
+	//trusted with XPO bearer tokens, like XPO's own alternate host
+	ForwardCredentials bool
+
+	mu         sync.Mutex
+	failures   int
+	failedOver time.Time //zero when using the primary
+}
+
+//RoundTrip sends the request to the primary or the fallback
+func (t *FailoverTransport) RoundTrip(req *http.Request) (res *http.Response, err error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	if EndpointFromContext(req.Context()) == EndpointToken || !t.useFallback() {
+		res, err = next.RoundTrip(req)
+		t.record(req, res, err)
+		return
+	}
+
+	//the request can't be changed so send a copy
+	fallbackReq := req.Clone(req.Context())
+	if !t.ForwardCredentials {
+		fallbackReq.Header.Del("Authorization")
+	}
+	fallbackReq.URL.Scheme = t.Fallback.Scheme
+	fallbackReq.URL.Host = t.Fallback.Host
+	fallbackReq.URL.Path = strings.TrimSuffix(t.Fallback.Path, "/") + req.URL.Path
+	fallbackReq.URL.RawPath = ""
+	fallbackReq.Host = ""
+
+	return next.RoundTrip(fallbackReq)
+}
+
+//Primary checks if requests are currently going to the primary
+func (t *FailoverTransport) Primary() bool {
+	return !t.useFallback()
+}
+
+//useFallback checks if a request should go to the fallback
+//Once FailbackAfter has passed the next request goes to the primary to see if it is back up.
+func (t *FailoverTransport) useFallback() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.failedOver.IsZero() {
+		return false
+	}
+
+	after := t.FailbackAfter
+	if after <= 0 {
+		after = DefaultFailbackAfter
+	}
+	if time.Since(t.failedOver) < after {
+		return true
+	}
+
+	//give the primary one chance, a failure sends us right back to the fallback
+	t.failedOver = time.Time{}
+	t.failures = t.threshold() - 1
+	return false
+}
+
+//record counts failed requests to the primary
+func (t *FailoverTransport) record(req *http.Request, res *http.Response, err error) {
+	//a request canceled by the caller says nothing about the primary
+	if err != nil && req.Context().Err() != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err == nil && res.StatusCode < http.StatusInternalServerError {
+		t.failures = 0
+		return
+	}
+
+	t.failures++
+	if t.failures >= t.threshold() {
+		t.failedOver = time.Now()
+	}
+	return
+}
+
+//threshold returns the failures in a row before failing over
+func (t *FailoverTransport) threshold() int {
+	if t.Threshold <= 0 {
+		return DefaultFailoverThreshold
+	}
+
+	return t.Threshold
+}
@@ -0,0 +1,150 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+//failoverServers starts a failing primary and a working fallback, recording the Authorization header each gets
+type failoverServers struct {
+	primary, fallback *httptest.Server
+
+	mu            sync.Mutex
+	primaryAuth   []string
+	fallbackAuth  []string
+	primaryStatus int
+}
+
+func newFailoverServers(t *testing.T) *failoverServers {
+	s := &failoverServers{primaryStatus: http.StatusServiceUnavailable}
+	s.primary = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.primaryAuth = append(s.primaryAuth, r.Header.Get("Authorization"))
+		w.WriteHeader(s.primaryStatus)
+	}))
+	s.fallback = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.fallbackAuth = append(s.fallbackAuth, r.Header.Get("Authorization"))
+	}))
+	t.Cleanup(s.primary.Close)
+	t.Cleanup(s.fallback.Close)
+
+	return s
+}
+
+//transport returns a FailoverTransport from the primary to the fallback
+func (s *failoverServers) transport(t *testing.T) *FailoverTransport {
+	fallback, err := url.Parse(s.fallback.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &FailoverTransport{Fallback: fallback, Threshold: 2}
+}
+
+//send sends a request for endpoint to the primary with a bearer token
+func (s *failoverServers) send(t *testing.T, rt http.RoundTripper, endpoint string) {
+	req, err := NewRequest(context.Background(), Request{
+		Endpoint:    endpoint,
+		Method:      http.MethodGet,
+		URL:         s.primary.URL + "/pickuprequest",
+		BearerToken: "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+}
+
+//counts returns the requests each server got
+func (s *failoverServers) counts() (primary, fallback int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.primaryAuth), len(s.fallbackAuth)
+}
+
+func TestFailoverTransport(t *testing.T) {
+	s := newFailoverServers(t)
+	rt := s.transport(t)
+
+	for i := 0; i < 3; i++ {
+		s.send(t, rt, "pickup")
+	}
+
+	if p, f := s.counts(); p != 2 || f != 1 {
+		t.Fatalf("got %d requests to the primary and %d to the fallback, want 2 and 1", p, f)
+	}
+	if rt.Primary() {
+		t.Error("got primary, want failed over")
+	}
+	if s.fallbackAuth[0] != "" {
+		t.Errorf("got Authorization %q sent to the fallback, want none", s.fallbackAuth[0])
+	}
+}
+
+func TestFailoverTransportForwardCredentials(t *testing.T) {
+	s := newFailoverServers(t)
+	rt := s.transport(t)
+	rt.ForwardCredentials = true
+
+	for i := 0; i < 3; i++ {
+		s.send(t, rt, "pickup")
+	}
+
+	if _, f := s.counts(); f != 1 || s.fallbackAuth[0] != "Bearer secret" {
+		t.Errorf("got Authorization %q sent to the fallback, want the bearer token", s.fallbackAuth)
+	}
+}
+
+func TestFailoverTransportToken(t *testing.T) {
+	s := newFailoverServers(t)
+	rt := s.transport(t)
+
+	for i := 0; i < 3; i++ {
+		s.send(t, rt, EndpointToken)
+	}
+
+	if p, f := s.counts(); p != 3 || f != 0 {
+		t.Errorf("got %d token requests to the primary and %d to the fallback, want all to the primary", p, f)
+	}
+}
+
+func TestFailoverTransportFailback(t *testing.T) {
+	s := newFailoverServers(t)
+	rt := s.transport(t)
+	rt.FailbackAfter = 10 * time.Millisecond
+
+	for i := 0; i < 2; i++ {
+		s.send(t, rt, "pickup")
+	}
+	if rt.Primary() {
+		t.Fatal("got primary, want failed over")
+	}
+
+	s.mu.Lock()
+	s.primaryStatus = http.StatusOK
+	s.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+
+	s.send(t, rt, "pickup")
+	s.send(t, rt, "pickup")
+	if p, f := s.counts(); p != 4 || f != 0 {
+		t.Errorf("got %d requests to the primary and %d to the fallback, want 4 and 0 after the primary recovered", p, f)
+	}
+	if !rt.Primary() {
+		t.Error("got failed over, want back on the primary")
+	}
+}
@@ -0,0 +1,58 @@
+package transport
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+)
+
+//HTTPVersion is which http version requests are made with
+type HTTPVersion int
+
+//http versions
+//HTTPDefault lets Go pick, which is HTTP/2 when XPO supports it.  Force HTTP/1.1 if HTTP/2 connections are
+//being reset, each request then gets its own connection from the pool.
+const (
+	HTTPDefault HTTPVersion = iota
+	HTTP1
+	HTTP2
+)
+
+//HTTPOptions tunes the connections made to XPO
+//Zero values keep Go's defaults.
+type HTTPOptions struct {
+	MaxIdleConns        int           //idle connections kept across all hosts
+	MaxIdleConnsPerHost int           //idle connections kept per host, Go defaults to only 2
+	MaxConnsPerHost     int           //limit on connections per host, including ones in use
+	IdleConnTimeout     time.Duration //how long an idle connection is kept
+	HTTPVersion         HTTPVersion
+}
+
+//NewHTTPTransport returns an http.Transport with Go's default settings changed by o
+func NewHTTPTransport(o HTTPOptions) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	if o.MaxIdleConns > 0 {
+		t.MaxIdleConns = o.MaxIdleConns
+	}
+	if o.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = o.MaxIdleConnsPerHost
+	}
+	if o.MaxConnsPerHost > 0 {
+		t.MaxConnsPerHost = o.MaxConnsPerHost
+	}
+	if o.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = o.IdleConnTimeout
+	}
+
+	switch o.HTTPVersion {
+	case HTTP1:
+		//a non-nil empty map turns off HTTP/2
+		t.ForceAttemptHTTP2 = false
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	case HTTP2:
+		t.ForceAttemptHTTP2 = true
+	}
+
+	return t
+}
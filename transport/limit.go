@@ -0,0 +1,51 @@
+package transport
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//Limiter spaces requests out so no more than a set number are sent per second
+//Requests wait their turn rather than being rejected.  A nil *Limiter doesn't limit anything.
+type Limiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+//NewLimiter returns a limiter allowing perSecond requests per second
+//A perSecond of 0 or less returns nil, meaning no limit.
+func NewLimiter(perSecond float64) *Limiter {
+	if perSecond <= 0 {
+		return nil
+	}
+
+	return &Limiter{
+		interval: time.Duration(float64(time.Second) / perSecond),
+	}
+}
+
+//Wait blocks until a request can be sent or ctx is done
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	d := at.Sub(now)
+	if d <= 0 {
+		return nil
+	}
+
+	return sleep(ctx, d)
+}
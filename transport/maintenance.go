@@ -0,0 +1,60 @@
+package transport
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+)
+
+//maintenancePeekSize is how much of an error response is read to check for XPO's maintenance page
+const maintenancePeekSize = 64 << 10
+
+//maintenanceRegex matches phrases in XPO's maintenance pages
+var maintenanceRegex = regexp.MustCompile(`(?i)(maintenance|scheduled (outage|downtime)|down for (an )?upgrade)`)
+
+//IsMaintenance checks if a response is XPO's maintenance page
+//Only 429 and 5xx responses with an html or plain text body are checked.  A successful response mentioning
+//maintenance, like in a remark, or an xml fault or json error whose description does, is XPO's API answering
+//and is never mistaken for an outage.
+func IsMaintenance(statusCode int, body []byte) bool {
+	if statusCode != http.StatusTooManyRequests && statusCode < http.StatusInternalServerError {
+		return false
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	switch sniff(trimmed) {
+	case contentHTML, contentUnknown:
+	case contentXML:
+		if _, isFault := decodeFault(trimmed).(*Fault); isFault {
+			return false
+		}
+	default:
+		return false
+	}
+
+	return maintenanceRegex.Match(body)
+}
+
+//peekMaintenance checks if res is XPO's maintenance page
+//The start of the body is read to check and then put back so the caller can still read the whole body.
+func peekMaintenance(res *http.Response) bool {
+	if res.StatusCode < http.StatusTooManyRequests || res.Body == nil {
+		return false
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(res.Body, maintenancePeekSize))
+	res.Body = peekedBody{
+		Reader: io.MultiReader(bytes.NewReader(b), res.Body),
+		Closer: res.Body,
+	}
+
+	return err == nil && IsMaintenance(res.StatusCode, b)
+}
+
+//peekedBody is a response body with the part already read put back in front
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,86 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+//NetworkErrorKind is what went wrong when a request couldn't reach XPO
+type NetworkErrorKind string
+
+//network error kinds
+//NetworkOther is used when the failure doesn't fit the others, like a connection reset mid request.
+const (
+	NetworkDNS     NetworkErrorKind = "dns"
+	NetworkConnect NetworkErrorKind = "connect"
+	NetworkTLS     NetworkErrorKind = "tls"
+	NetworkTimeout NetworkErrorKind = "timeout"
+	NetworkOther   NetworkErrorKind = "other"
+)
+
+//NetworkError is returned when a request failed before XPO could respond
+//This is our network, or XPO's, being broken as opposed to XPO rejecting the request.  The underlying error,
+//usually a *url.Error, is still available through errors.As.
+type NetworkError struct {
+	Kind NetworkErrorKind
+	Err  error
+}
+
+//Error includes the kind of failure
+func (n *NetworkError) Error() string {
+	return "network error (" + string(n.Kind) + "): " + n.Err.Error()
+}
+
+//Unwrap returns the underlying error
+func (n *NetworkError) Unwrap() error {
+	return n.Err
+}
+
+//Timeout checks if the request timed out
+func (n *NetworkError) Timeout() bool {
+	return n.Kind == NetworkTimeout
+}
+
+//newNetworkError classifies why a request failed
+//connecting is true if the request failed while still waiting on a connection to XPO, so it was never sent.
+//Checks go from most to least specific.  A dial that times out, like to a host that drops every packet, is a
+//connect failure, not a timeout, since the request never left.  http.Client.Timeout replaces the dial error
+//with its own so connecting is used to tell those apart too.
+func newNetworkError(err error, connecting bool) *NetworkError {
+	n := &NetworkError{
+		Kind: NetworkOther,
+		Err:  err,
+	}
+
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	var recordErr tls.RecordHeaderError
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+
+	timeout := errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrPhaseTimeout) || errors.As(err, &netErr) && netErr.Timeout()
+
+	switch {
+	case errors.As(err, &dnsErr):
+		n.Kind = NetworkDNS
+	case errors.As(err, &opErr) && opErr.Op == "dial", timeout && connecting:
+		n.Kind = NetworkConnect
+	case timeout:
+		n.Kind = NetworkTimeout
+	case errors.As(err, &certErr), errors.As(err, &unknownAuthErr), errors.As(err, &hostnameErr),
+		errors.As(err, &invalidErr), errors.As(err, &recordErr):
+		n.Kind = NetworkTLS
+	case errors.As(err, &opErr) && opErr.Op == "remote error":
+		//tls alerts from the server come back as an OpError with this op
+		n.Kind = NetworkTLS
+	}
+
+	return n
+}
@@ -0,0 +1,248 @@
+package transport
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//retry defaults
+const (
+	DefaultMaxRetries = 3
+	DefaultBaseDelay  = 500 * time.Millisecond
+	DefaultMaxDelay   = 10 * time.Second
+)
+
+//Backoff decides how long to wait before a retry
+//attempt is 0 for the delay before the first retry.
+type Backoff interface {
+	Delay(attempt int) time.Duration
+}
+
+//BackoffFunc adapts a func to a Backoff
+type BackoffFunc func(attempt int) time.Duration
+
+//Delay calls f
+func (f BackoffFunc) Delay(attempt int) time.Duration {
+	return f(attempt)
+}
+
+//ExponentialBackoff doubles the delay each attempt, with full jitter so many clients retrying at once spread out
+type ExponentialBackoff struct {
+	Base time.Duration //delay before the first retry, defaults to DefaultBaseDelay
+	Max  time.Duration //longest delay between retries, defaults to DefaultMaxDelay
+}
+
+//Delay returns a random delay up to Base doubled attempt times
+func (b ExponentialBackoff) Delay(attempt int) time.Duration {
+	base := b.Base
+	if base <= 0 {
+		base = DefaultBaseDelay
+	}
+	max := b.Max
+	if max <= 0 {
+		max = DefaultMaxDelay
+	}
+
+	d := base << uint(attempt)
+	if d > max || d <= 0 {
+		d = max
+	}
+	return time.Duration(rand.Int63n(int64(d)) + 1)
+}
+
+//ConstantBackoff waits the same amount of time before every retry
+type ConstantBackoff time.Duration
+
+//Delay returns b
+func (b ConstantBackoff) Delay(attempt int) time.Duration {
+	return time.Duration(b)
+}
+
+//RetryBudget limits retries for one endpoint
+//Some calls can wait a long time for an answer, like a background job, while others are made while a user waits
+//and should give up quickly.  Zero fields fall back to the RetryTransport's settings.
+type RetryBudget struct {
+	MaxRetries int           //set to -1 for no retries
+	Backoff    Backoff       //delay between retries
+	MaxElapsed time.Duration //stop retrying once this much time has passed since the first attempt
+}
+
+//RetryTransport is an http.RoundTripper that retries requests XPO didn't process
+//A request is only retried when it is safe to send it again: the connection could not be made (DNS,
+//refused, etc.) so the request never left, XPO responded 429 or 503 meaning it didn't process the request,
+//or the request is a GET/HEAD/OPTIONS that failed any other way or got a 502/504.  POSTs that might have
+//reached XPO are never retried since that could book a pickup twice.  Delays grow exponentially with
+//jitter unless another Backoff is given, and a Retry-After header from XPO is respected.  A request with a body
+//that can't be rewound, no GetBody, is only sent once.  XPO's maintenance page is never retried, the window
+//lasts far longer than any retry so the caller should back off instead, see IsMaintenance.
+//
+//Budgets are looked up by the endpoint name that Transport adds to the request's context, see WithEndpoint.
+type RetryTransport struct {
+	Next       http.RoundTripper      //defaults to http.DefaultTransport
+	MaxRetries int                    //defaults to DefaultMaxRetries, set to -1 for no retries
+	BaseDelay  time.Duration          //delay before the first retry, defaults to DefaultBaseDelay
+	MaxDelay   time.Duration          //longest delay between retries, defaults to DefaultMaxDelay
+	Backoff    Backoff                //defaults to ExponentialBackoff using BaseDelay and MaxDelay
+	Budgets    map[string]RetryBudget //per endpoint overrides, keyed by endpoint name
+}
+
+//RoundTrip sends the request, retrying as needed
+func (t *RetryTransport) RoundTrip(req *http.Request) (res *http.Response, err error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	budget := t.budget(EndpointFromContext(req.Context()))
+	start := time.Now()
+
+	for attempt := 0; ; attempt++ {
+		//each retry gets its own copy of the request so the caller's request, and its body, aren't changed
+		r := req
+		if attempt > 0 {
+			r, err = retryRequest(req)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		res, err = next.RoundTrip(r)
+		if attempt >= budget.MaxRetries || !shouldRetry(req, res, err) || !rewindable(req) {
+			return
+		}
+
+		delay := t.delay(budget.Backoff, attempt, res)
+
+		//out of time for this endpoint, return what we have
+		if budget.MaxElapsed > 0 && time.Since(start)+delay > budget.MaxElapsed {
+			return
+		}
+
+		//done with this response, the retry will get a new one
+		if res != nil {
+			res.Body.Close()
+		}
+
+		err = sleep(req.Context(), delay)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+//rewindable checks if a request's body can be sent again
+func rewindable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+//retryRequest copies a request with a fresh body for a retry
+//The body was read by the last attempt, GetBody gives a new copy of it.
+func retryRequest(req *http.Request) (r *http.Request, err error) {
+	r = req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+
+	r.Body, err = req.GetBody()
+	if err != nil {
+		err = errors.Wrap(err, "transport.RoundTrip - could not reset request body for retry")
+		return
+	}
+
+	return
+}
+
+//budget returns the retry settings for an endpoint with defaults filled in
+func (t *RetryTransport) budget(endpoint string) (b RetryBudget) {
+	b = t.Budgets[endpoint]
+
+	if b.MaxRetries == 0 {
+		b.MaxRetries = t.MaxRetries
+	}
+	if b.MaxRetries == 0 {
+		b.MaxRetries = DefaultMaxRetries
+	}
+
+	if b.Backoff == nil {
+		b.Backoff = t.Backoff
+	}
+	if b.Backoff == nil {
+		b.Backoff = ExponentialBackoff{Base: t.BaseDelay, Max: t.MaxDelay}
+	}
+
+	return
+}
+
+//delay returns how long to wait before the retry after attempt
+func (t *RetryTransport) delay(backoff Backoff, attempt int, res *http.Response) time.Duration {
+	//XPO told us how long to wait
+	if res != nil {
+		if s, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && s > 0 {
+			max := t.MaxDelay
+			if max <= 0 {
+				max = DefaultMaxDelay
+			}
+
+			d := time.Duration(s) * time.Second
+			if d > max {
+				d = max
+			}
+			return d
+		}
+	}
+
+	return backoff.Delay(attempt)
+}
+
+//shouldRetry decides if a request can be sent again
+func shouldRetry(req *http.Request, res *http.Response, err error) bool {
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead || req.Method == http.MethodOptions
+
+	if err != nil {
+		//canceled by the caller, don't keep going
+		if req.Context().Err() != nil {
+			return false
+		}
+
+		//couldn't connect so the request never made it to XPO
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return true
+		}
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) {
+			return true
+		}
+
+		return idempotent
+	}
+
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		//XPO is down for a maintenance window, retrying within seconds won't get through
+		return !peekMaintenance(res)
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent
+	default:
+		return false
+	}
+}
+
+//sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
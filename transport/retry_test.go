@@ -0,0 +1,113 @@
+package transport
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+//noBackoff retries right away so tests don't wait
+var noBackoff = ConstantBackoff(0)
+
+func TestRetryTransportResendsBody(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		if string(b) != "payload" {
+			t.Errorf("got body %q on attempt %d", b, atomic.LoadInt32(&attempts)+1)
+		}
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := req.Body
+
+	rt := &RetryTransport{Backoff: noBackoff}
+	res, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK || attempts != 3 {
+		t.Errorf("got status %d after %d attempts, want 200 after 3", res.StatusCode, attempts)
+	}
+	if req.Body != body {
+		t.Error("the caller's request body was replaced")
+	}
+}
+
+func TestRetryTransportBodyNotRewindable(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("down"))
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.GetBody = nil
+
+	rt := &RetryTransport{Backoff: noBackoff}
+	res, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if attempts != 1 {
+		t.Errorf("got %d attempts, want 1 since the body can't be resent", attempts)
+	}
+
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil || string(b) != "down" {
+		t.Errorf("got body %q and error %v, want the response to still be readable", b, err)
+	}
+}
+
+func TestRetryTransportMaintenance(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`<html><body><h1>XPO is down for scheduled maintenance</h1></body></html>`))
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rt := &RetryTransport{Backoff: noBackoff}
+	res, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if attempts != 1 {
+		t.Errorf("got %d attempts, want 1 for a maintenance page", attempts)
+	}
+
+	b, _ := ioutil.ReadAll(res.Body)
+	if !strings.Contains(string(b), "scheduled maintenance") {
+		t.Errorf("got body %q, want the whole maintenance page", b)
+	}
+}
@@ -0,0 +1,83 @@
+package transport
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+)
+
+//MinGzipSize is the smallest body that is compressed, smaller bodies aren't worth the time
+const MinGzipSize = 1024
+
+//BodyWriter writes a request body to w
+//It may be called more than once for the same request if the request is retried, so it must write the same
+//body every time.
+type BodyWriter func(w io.Writer) error
+
+//streamBody is a request body that is written by a BodyWriter as it is read
+//Nothing is written until the first Read so a request that is built but never sent doesn't leave a goroutine
+//blocked on the pipe.
+type streamBody struct {
+	write BodyWriter
+
+	once sync.Once
+	pr   *io.PipeReader
+	pw   *io.PipeWriter
+}
+
+//newStreamBody returns a body that streams what write writes
+func newStreamBody(write BodyWriter) *streamBody {
+	pr, pw := io.Pipe()
+	return &streamBody{
+		write: write,
+		pr:    pr,
+		pw:    pw,
+	}
+}
+
+//Read starts the writer on the first call and reads what it has written
+func (s *streamBody) Read(p []byte) (int, error) {
+	s.once.Do(func() {
+		go func() {
+			s.pw.CloseWithError(s.write(s.pw))
+		}()
+	})
+
+	return s.pr.Read(p)
+}
+
+//Close stops the writer if it is still going
+func (s *streamBody) Close() error {
+	return s.pr.CloseWithError(io.ErrClosedPipe)
+}
+
+//gzipBody returns r with its body compressed
+//A Body is compressed now so its length is known, a BodyWriter is compressed as it is streamed.
+func gzipBody(r Request) (Request, error) {
+	if write := r.BodyWriter; write != nil {
+		r.BodyWriter = func(w io.Writer) error {
+			gz := gzip.NewWriter(w)
+			err := write(gz)
+			if err != nil {
+				return err
+			}
+			return gz.Close()
+		}
+		return r, nil
+	}
+
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	_, err := gz.Write(r.Body)
+	if err != nil {
+		return r, err
+	}
+	err = gz.Close()
+	if err != nil {
+		return r, err
+	}
+
+	r.Body = b.Bytes()
+	return r, nil
+}
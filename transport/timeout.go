@@ -0,0 +1,213 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//Timeouts limits how long each part of a request can take
+//Zero means no limit for that part.
+type Timeouts struct {
+	Dial           time.Duration //connecting to XPO
+	TLSHandshake   time.Duration //setting up https once connected
+	ResponseHeader time.Duration //waiting for XPO to start responding once the request is sent
+	Body           time.Duration //reading the response body once XPO starts responding
+	Total          time.Duration //the whole request, including any retries
+}
+
+//merge fills in zero fields from d
+func (t Timeouts) merge(d Timeouts) Timeouts {
+	if t.Dial == 0 {
+		t.Dial = d.Dial
+	}
+	if t.TLSHandshake == 0 {
+		t.TLSHandshake = d.TLSHandshake
+	}
+	if t.ResponseHeader == 0 {
+		t.ResponseHeader = d.ResponseHeader
+	}
+	if t.Body == 0 {
+		t.Body = d.Body
+	}
+	if t.Total == 0 {
+		t.Total = d.Total
+	}
+	return t
+}
+
+//ErrPhaseTimeout is wrapped by the error returned when a part of a request took too long
+var ErrPhaseTimeout = errors.New("transport - request timed out")
+
+//TimeoutTransport is an http.RoundTripper that applies different timeouts to different endpoints
+//Fetching a token should fail fast while a large download needs minutes, one http.Client.Timeout can't do
+//both.  Timeouts are looked up by the endpoint name in the request's context, see WithEndpoint, and zero
+//fields fall back to Default.  Don't set http.Client.Timeout when using this, use Default.Total instead.
+type TimeoutTransport struct {
+	Next      http.RoundTripper   //defaults to http.DefaultTransport
+	Default   Timeouts            //used for endpoints not in Endpoints
+	Endpoints map[string]Timeouts //per endpoint overrides, keyed by endpoint name
+}
+
+//RoundTrip sends the request, canceling it if any part takes too long
+func (t *TimeoutTransport) RoundTrip(req *http.Request) (res *http.Response, err error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	timeouts := t.Endpoints[EndpointFromContext(req.Context())].merge(t.Default)
+
+	ctx, cancel := context.WithCancel(req.Context())
+	p := &phaseTimer{cancel: cancel}
+	if timeouts.Total > 0 {
+		p.start("total", timeouts.Total)
+	}
+
+	//the connection phases can happen more than once if the request is retried
+	trace := &httptrace.ClientTrace{
+		ConnectStart: func(network, addr string) {
+			p.start("dial", timeouts.Dial)
+		},
+		ConnectDone: func(network, addr string, err error) {
+			p.stop("dial")
+		},
+		TLSHandshakeStart: func() {
+			p.start("tls handshake", timeouts.TLSHandshake)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			p.stop("tls handshake")
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			p.start("response header", timeouts.ResponseHeader)
+		},
+		GotFirstResponseByte: func() {
+			p.stop("response header")
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(ctx, trace))
+
+	res, err = next.RoundTrip(req)
+	if err != nil {
+		if phase := p.expiredPhase(); phase != "" {
+			err = errors.Wrapf(ErrPhaseTimeout, "%s took too long", phase)
+		}
+		p.stopAll()
+		cancel()
+		return
+	}
+
+	p.start("body", timeouts.Body)
+	res.Body = &timeoutBody{
+		ReadCloser: res.Body,
+		timer:      p,
+	}
+	return
+}
+
+//phaseTimer cancels a request when one of its phases runs too long
+type phaseTimer struct {
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	timers   map[string]*time.Timer
+	timedOut string //phase that timed out
+}
+
+//start starts timing a phase, a zero d doesn't time it
+func (p *phaseTimer) start(phase string, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.timers == nil {
+		p.timers = map[string]*time.Timer{}
+	}
+	if t, ok := p.timers[phase]; ok {
+		t.Stop()
+	}
+	p.timers[phase] = time.AfterFunc(d, func() {
+		p.mu.Lock()
+		if p.timedOut == "" {
+			p.timedOut = phase
+		}
+		p.mu.Unlock()
+		p.cancel()
+	})
+}
+
+//stop stops timing a phase
+func (p *phaseTimer) stop(phase string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if t, ok := p.timers[phase]; ok {
+		t.Stop()
+		delete(p.timers, phase)
+	}
+}
+
+//stopAll stops every timer
+func (p *phaseTimer) stopAll() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for phase, t := range p.timers {
+		t.Stop()
+		delete(p.timers, phase)
+	}
+}
+
+//expiredPhase returns the phase that timed out, if any
+func (p *phaseTimer) expiredPhase() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.timedOut
+}
+
+//timeoutBody stops the timers and releases the request's context once the body is read or closed
+type timeoutBody struct {
+	io.ReadCloser
+	timer *phaseTimer
+	once  sync.Once
+}
+
+//Read reads the body, returning a timeout error if the body or whole request took too long
+func (b *timeoutBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.done()
+		return
+	}
+	if err != nil {
+		if phase := b.timer.expiredPhase(); phase != "" {
+			err = errors.Wrapf(ErrPhaseTimeout, "%s took too long", phase)
+		}
+	}
+	return
+}
+
+//Close closes the body
+func (b *timeoutBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.done()
+	return err
+}
+
+//done stops the timers and cancels the context, which is no longer needed
+func (b *timeoutBody) done() {
+	b.once.Do(func() {
+		b.timer.stopAll()
+		b.timer.cancel()
+	})
+}
@@ -0,0 +1,428 @@
+/*Package transport handles the low level details of talking to the XPO API: getting bearer tokens, making
+authorized requests, and decoding XPO's responses.
+
+XPO takes in JSON and returns JSON when a request is successful, but returns an XML fault when something
+goes wrong.  Decode() handles both so each endpoint wrapper doesn't have to.  Endpoint wrappers build a
+Request, send it with Transport.Do(), and decode the body into their own response type.
+*/
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//TokenURL is where bearer tokens are retrieved from
+const TokenURL = "https://api.ltl.xpo.com/token"
+
+//EndpointToken is the endpoint name used for token requests
+const EndpointToken = "token"
+
+//Credentials are what is needed to get a bearer token
+type Credentials struct {
+	Username    string //website login
+	Password    string
+	AccessToken string //used to retrieve bearer tokens, keep this secret
+}
+
+//TokenResponse is the data returned when we retrieve the bearer token
+type TokenResponse struct {
+	BearerToken  string `json:"access_token"`  //not the same as our account access token even though xpo sometimes calls them the same thing
+	RefreshToken string `json:"refresh_token"` //some other token, used to get a new pair of bearer & access tokens
+	Scope        string `json:"scope"`         //default
+	TokenType    string `json:"token_type"`    //Bearer
+	ExpiresIn    uint   `json:"expires_in"`    //43200
+}
+
+//Fault is the data returned when XPO rejects a request
+//XPO API takes in JSON but returns XML upon error
+//each field starts with "am:" but that can be excluded from struct tags
+type Fault struct {
+	XMLName     xml.Name `xml:"fault"`
+	Code        string   `xml:"code"`
+	Type        string   `xml:"type"`
+	Message     string   `xml:"message"`
+	Description string   `xml:"description"`
+}
+
+//Error returns XPO's description of the fault, or the message if there is no description
+func (f *Fault) Error() string {
+	if f.Description != "" {
+		return f.Description
+	}
+
+	return f.Message
+}
+
+//MaxResponseSize is the largest response body that will be read
+//Nothing XPO returns for the JSON endpoints should be anywhere near this, anything bigger is garbage.
+const MaxResponseSize = 10 << 20
+
+//ResponseErrorKind is what was wrong with a response that could not be decoded
+type ResponseErrorKind string
+
+//response error kinds
+const (
+	ResponseEmpty     ResponseErrorKind = "empty response"
+	ResponseHTML      ResponseErrorKind = "html response"
+	ResponseTruncated ResponseErrorKind = "truncated response"
+	ResponseTooLarge  ResponseErrorKind = "response too large"
+	ResponseMalformed ResponseErrorKind = "malformed response"
+)
+
+//snippetLength is how much of a bad body is kept on a ResponseError for logging
+const snippetLength = 256
+
+//ResponseError is returned when XPO sends back something that isn't JSON or an XML fault
+//This is usually an html error page from a proxy or load balancer, or a body that got cut off.
+type ResponseError struct {
+	Kind    ResponseErrorKind
+	Snippet string //start of the body, for logging
+	Err     error  //underlying decode error, if any
+}
+
+//Error describes the bad response
+func (r *ResponseError) Error() string {
+	msg := "transport - " + string(r.Kind)
+	if r.Err != nil {
+		msg += ": " + r.Err.Error()
+	}
+	if r.Snippet != "" {
+		msg += ": " + strconv.Quote(r.Snippet)
+	}
+
+	return msg
+}
+
+//Unwrap returns the underlying decode error
+func (r *ResponseError) Unwrap() error {
+	return r.Err
+}
+
+//newResponseError builds a ResponseError with a snippet of the body
+func newResponseError(kind ResponseErrorKind, body []byte, err error) *ResponseError {
+	snippet := bytes.TrimSpace(body)
+	if len(snippet) > snippetLength {
+		snippet = snippet[:snippetLength]
+	}
+
+	return &ResponseError{
+		Kind:    kind,
+		Snippet: strings.ToValidUTF8(string(snippet), "?"),
+		Err:     err,
+	}
+}
+
+//content types found by sniffing a body
+const (
+	contentEmpty = iota
+	contentJSON
+	contentXML
+	contentHTML
+	contentUnknown
+)
+
+//sniff guesses what kind of content a trimmed body is
+//The Content-Type header isn't trusted since XPO's gateway doesn't always set it correctly.
+func sniff(b []byte) int {
+	if len(b) == 0 {
+		return contentEmpty
+	}
+
+	switch b[0] {
+	case '{', '[':
+		return contentJSON
+	case '<':
+		start := bytes.ToLower(b)
+		if len(start) > 512 {
+			start = start[:512]
+		}
+		if bytes.Contains(start, []byte("<html")) || bytes.HasPrefix(start, []byte("<!doctype html")) {
+			return contentHTML
+		}
+		return contentXML
+	default:
+		return contentUnknown
+	}
+}
+
+//Request is a request to an XPO endpoint
+type Request struct {
+	Endpoint    string //name of the endpoint, used when observing requests
+	Method      string //defaults to POST
+	URL         string
+	Body        []byte
+	BodyWriter  BodyWriter //streams the body instead of using Body, keeps memory flat for large payloads
+	Gzip        bool       //compress the body, Body is only compressed when at least MinGzipSize
+	ContentType string     //defaults to application/json
+
+	//one of these is used for the Authorization header
+	BearerToken string
+	BasicToken  string
+}
+
+//Response is what XPO sent back
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+//ObserveFunc is called after every request with how long it took and how it went
+//statusCode is 0 if no response was received.
+type ObserveFunc func(endpoint string, d time.Duration, statusCode int, err error)
+
+//Transport sends requests to XPO
+type Transport struct {
+	Client  *http.Client //defaults to http.DefaultClient
+	Observe ObserveFunc  //optional
+	Limiter *Limiter     //optional, limits how fast requests are sent
+}
+
+//endpointKey is the context key for the endpoint name of a request
+type endpointKey struct{}
+
+//WithEndpoint returns a context that carries the name of the endpoint being called
+//This lets http.RoundTrippers, like RetryTransport, treat endpoints differently.
+func WithEndpoint(ctx context.Context, endpoint string) context.Context {
+	return context.WithValue(ctx, endpointKey{}, endpoint)
+}
+
+//EndpointFromContext returns the endpoint name set by WithEndpoint, or "" if there isn't one
+func EndpointFromContext(ctx context.Context) string {
+	endpoint, _ := ctx.Value(endpointKey{}).(string)
+	return endpoint
+}
+
+//NewRequest builds the http request for r
+//The endpoint name is added to the request's context, see WithEndpoint.
+func NewRequest(ctx context.Context, r Request) (req *http.Request, err error) {
+	method := r.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	if r.Endpoint != "" {
+		ctx = WithEndpoint(ctx, r.Endpoint)
+	}
+
+	gzipped := r.Gzip && (r.BodyWriter != nil || len(r.Body) >= MinGzipSize)
+	if gzipped {
+		r, err = gzipBody(r)
+		if err != nil {
+			err = errors.Wrap(err, "transport.NewRequest - could not compress body")
+			return
+		}
+	}
+
+	var body io.Reader = bytes.NewReader(r.Body)
+	if r.BodyWriter != nil {
+		body = newStreamBody(r.BodyWriter)
+	}
+
+	req, err = http.NewRequestWithContext(ctx, method, r.URL, body)
+	if err != nil {
+		err = errors.Wrap(err, "transport.NewRequest - could not build request")
+		return
+	}
+
+	//the length isn't known ahead of time so the body is sent chunked, GetBody lets the body be resent on a retry
+	if r.BodyWriter != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			return newStreamBody(r.BodyWriter), nil
+		}
+	}
+
+	contentType := r.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	req.Header.Set("Content-Type", contentType)
+	if gzipped {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	switch {
+	case r.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+r.BearerToken)
+	case r.BasicToken != "":
+		req.Header.Set("Authorization", "Basic "+r.BasicToken)
+	}
+
+	return
+}
+
+//Do sends a request to XPO and reads the response
+//An error is only returned if the request could not be made or the response could not be read.  XPO rejecting
+//the request is not an error here, that is found when the body is decoded.
+func (t *Transport) Do(ctx context.Context, r Request) (res Response, err error) {
+	req, err := NewRequest(ctx, r)
+	if err != nil {
+		return
+	}
+
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	err = t.Limiter.Wait(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "transport.Do - canceled waiting for rate limit")
+		return
+	}
+
+	start := time.Now()
+	defer func() {
+		if t.Observe != nil {
+			t.Observe(r.Endpoint, time.Since(start), res.StatusCode, err)
+		}
+	}()
+
+	//note if the request was still waiting on a connection so a failure can be classified as before or after
+	//the request was sent
+	var connecting, connected atomic.Bool
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		GetConn: func(string) {
+			connecting.Store(true)
+		},
+		GotConn: func(httptrace.GotConnInfo) {
+			connected.Store(true)
+		},
+	}))
+
+	httpRes, err := client.Do(req)
+	if err != nil {
+		err = errors.Wrap(newNetworkError(err, connecting.Load() && !connected.Load()), "transport.Do - could not make request")
+		return
+	}
+	defer httpRes.Body.Close()
+
+	res.StatusCode = httpRes.StatusCode
+	res.Header = httpRes.Header
+	//read one byte past the limit so we know if the body was too big
+	res.Body, err = ioutil.ReadAll(io.LimitReader(httpRes.Body, MaxResponseSize+1))
+	if err != nil {
+		err = errors.Wrap(err, "transport.Do - could not read response")
+		return
+	}
+	if len(res.Body) > MaxResponseSize {
+		err = newResponseError(ResponseTooLarge, res.Body, nil)
+		res.Body = res.Body[:MaxResponseSize]
+		return
+	}
+
+	return
+}
+
+//TokenForm is the form body sent to get a bearer token
+//includePassword is false when building the form for logging or auditing.
+func TokenForm(c Credentials, includePassword bool) []byte {
+	v := url.Values{}
+	v.Add("grant_type", "password")
+	v.Add("username", c.Username)
+	if includePassword {
+		v.Add("password", c.Password)
+	}
+
+	return []byte(v.Encode())
+}
+
+//Token gets a "bearer" token we can use to make requests to the other endpoints
+//We request this temporary token using our permanent access token.
+func (t *Transport) Token(ctx context.Context, tokenURL string, c Credentials) (token TokenResponse, res Response, err error) {
+	if tokenURL == "" {
+		tokenURL = TokenURL
+	}
+
+	//headers set per xpo
+	r := Request{
+		Endpoint:    EndpointToken,
+		URL:         tokenURL,
+		Body:        TokenForm(c, true),
+		ContentType: "application/x-www-form-urlencoded",
+		BasicToken:  c.AccessToken,
+	}
+	res, err = t.Do(ctx, r)
+	if err != nil {
+		return
+	}
+
+	err = Decode(res.Body, &token)
+	if err != nil {
+		err = errors.Wrap(err, "transport.Token - could not decode response")
+		return
+	}
+
+	//make sure we got a bearer token back
+	if token.BearerToken == "" {
+		err = errors.New("transport.Token - could not get bearer token from response body: " + string(res.Body))
+		return
+	}
+
+	return
+}
+
+//Decode unmarshals a response body into v
+//If the body is an XML fault instead of JSON, a *Fault is returned as the error.  Any other body that can't
+//be decoded (empty, an html error page, truncated, or otherwise garbage) returns a *ResponseError.
+func Decode(body []byte, v interface{}) (err error) {
+	trimmed := bytes.TrimSpace(body)
+
+	switch sniff(trimmed) {
+	case contentEmpty:
+		return newResponseError(ResponseEmpty, body, nil)
+	case contentHTML:
+		return newResponseError(ResponseHTML, body, nil)
+	case contentXML:
+		err = decodeFault(trimmed)
+		if _, ok := err.(*Fault); ok {
+			return
+		}
+		return newResponseError(ResponseMalformed, body, err)
+	case contentJSON:
+		err = json.Unmarshal(trimmed, v)
+		if err == nil {
+			return
+		}
+
+		//a body cut off part way through runs out of input before the json is complete
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) && strings.Contains(syntaxErr.Error(), "unexpected end of JSON input") {
+			return newResponseError(ResponseTruncated, body, err)
+		}
+		return newResponseError(ResponseMalformed, body, err)
+	default:
+		return newResponseError(ResponseMalformed, body, nil)
+	}
+}
+
+//decodeFault unmarshals an XML fault
+//the fault is returned as the error when it was decoded, otherwise the xml error is returned.
+func decodeFault(body []byte) error {
+	var f Fault
+	err := xml.Unmarshal(body, &f)
+	if err != nil {
+		return errors.Wrap(err, "transport.Decode - could not unmarshal xml fault")
+	}
+
+	//an empty fault doesn't tell the caller anything, treat it as garbage
+	if strings.TrimSpace(f.Code+f.Message+f.Description) == "" {
+		return errors.New("transport.Decode - empty xml fault")
+	}
+
+	return &f
+}
@@ -0,0 +1,300 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		kind ResponseErrorKind //expected kind of *ResponseError, "" if none
+		code string            //expected *Fault code, "" if none
+	}{
+		{name: "json", body: `{"access_token":"abc","expires_in":43200}`},
+		{name: "json with whitespace", body: "\n  {\"access_token\":\"abc\"}  \n"},
+		{
+			name: "xml fault",
+			body: `<am:fault xmlns:am="http://wso2.org/apimanager"><am:code>900901</am:code><am:type>Status report</am:type><am:message>Runtime Error</am:message><am:description>Invalid Credentials</am:description></am:fault>`,
+			code: "900901",
+		},
+		{name: "empty xml fault", body: `<am:fault xmlns:am="http://wso2.org/apimanager"></am:fault>`, kind: ResponseMalformed},
+		{name: "html page", body: `<!DOCTYPE html><html><body><h1>502 Bad Gateway</h1></body></html>`, kind: ResponseHTML},
+		{name: "html page without doctype", body: `<HTML><body>Service Unavailable</body></HTML>`, kind: ResponseHTML},
+		{name: "truncated", body: `{"access_token":"abc","expires_`, kind: ResponseTruncated},
+		{name: "malformed json", body: `{"access_token":abc}`, kind: ResponseMalformed},
+		{name: "plain text", body: `upstream connect error`, kind: ResponseMalformed},
+		{name: "empty", body: ``, kind: ResponseEmpty},
+		{name: "whitespace only", body: " \r\n\t", kind: ResponseEmpty},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var token TokenResponse
+			err := Decode([]byte(tt.body), &token)
+
+			var fault *Fault
+			var resErr *ResponseError
+			switch {
+			case tt.code != "":
+				if !errors.As(err, &fault) {
+					t.Fatalf("got %v, want a *Fault", err)
+				}
+				if fault.Code != tt.code {
+					t.Errorf("got fault code %q, want %q", fault.Code, tt.code)
+				}
+			case tt.kind != "":
+				if !errors.As(err, &resErr) {
+					t.Fatalf("got %v, want a *ResponseError", err)
+				}
+				if resErr.Kind != tt.kind {
+					t.Errorf("got kind %q, want %q", resErr.Kind, tt.kind)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("got %v, want no error", err)
+				}
+				if token.BearerToken != "abc" {
+					t.Errorf("got bearer token %q, want abc", token.BearerToken)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeSnippet(t *testing.T) {
+	body := "<html>" + strings.Repeat("x", 2*snippetLength) + "</html>"
+
+	err := Decode([]byte(body), &TokenResponse{})
+
+	var resErr *ResponseError
+	if !errors.As(err, &resErr) {
+		t.Fatalf("got %v, want a *ResponseError", err)
+	}
+	if len(resErr.Snippet) != snippetLength {
+		t.Errorf("got snippet of %d bytes, want %d", len(resErr.Snippet), snippetLength)
+	}
+}
+
+func TestToken(t *testing.T) {
+	creds := Credentials{Username: "user", Password: "secret", AccessToken: "access"}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("got method %s, want POST", r.Method)
+		}
+		if got := r.Header.Get("Authorization"); got != "Basic access" {
+			t.Errorf("got Authorization %q, want the access token", got)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/x-www-form-urlencoded" {
+			t.Errorf("got Content-Type %q, want a form", got)
+		}
+
+		b, _ := ioutil.ReadAll(r.Body)
+		form, err := url.ParseQuery(string(b))
+		if err != nil {
+			t.Errorf("could not parse form: %v", err)
+		}
+		if form.Get("grant_type") != "password" || form.Get("username") != "user" || form.Get("password") != "secret" {
+			t.Errorf("got form %v", form)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"bearer","refresh_token":"refresh","scope":"default","token_type":"Bearer","expires_in":43200}`))
+	}))
+	defer srv.Close()
+
+	tr := &Transport{Client: srv.Client()}
+	token, res, err := tr.Token(context.Background(), srv.URL, creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("got status %d, want 200", res.StatusCode)
+	}
+	if token.BearerToken != "bearer" || token.ExpiresIn != 43200 {
+		t.Errorf("got token %+v", token)
+	}
+}
+
+func TestTokenFault(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`<am:fault xmlns:am="http://wso2.org/apimanager"><am:code>900901</am:code><am:message>Unauthorized</am:message><am:description>Invalid Credentials</am:description></am:fault>`))
+	}))
+	defer srv.Close()
+
+	tr := &Transport{Client: srv.Client()}
+	_, res, err := tr.Token(context.Background(), srv.URL, Credentials{AccessToken: "bad"})
+
+	var fault *Fault
+	if !errors.As(err, &fault) {
+		t.Fatalf("got %v, want a *Fault", err)
+	}
+	if fault.Code != "900901" {
+		t.Errorf("got fault code %q, want 900901", fault.Code)
+	}
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("got status %d, want 401", res.StatusCode)
+	}
+}
+
+func TestTokenMissingBearer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"token_type":"Bearer"}`))
+	}))
+	defer srv.Close()
+
+	tr := &Transport{Client: srv.Client()}
+	_, _, err := tr.Token(context.Background(), srv.URL, Credentials{AccessToken: "access"})
+	if err == nil {
+		t.Fatal("got no error for a response without a bearer token")
+	}
+}
+
+func TestTokenFormWithoutPassword(t *testing.T) {
+	form := string(TokenForm(Credentials{Username: "user", Password: "secret"}, false))
+	if strings.Contains(form, "secret") {
+		t.Errorf("got password in form %q", form)
+	}
+}
+
+func TestNewRequestHeaders(t *testing.T) {
+	basic := base64.StdEncoding.EncodeToString([]byte("id:secret"))
+
+	tests := []struct {
+		name          string
+		r             Request
+		method        string
+		contentType   string
+		authorization string
+		encoding      string
+	}{
+		{
+			name:          "bearer",
+			r:             Request{URL: "https://example.com", Body: []byte(`{}`), BearerToken: "abc"},
+			method:        http.MethodPost,
+			contentType:   "application/json",
+			authorization: "Bearer abc",
+		},
+		{
+			name:          "basic",
+			r:             Request{URL: "https://example.com", ContentType: "application/x-www-form-urlencoded", BasicToken: basic},
+			method:        http.MethodPost,
+			contentType:   "application/x-www-form-urlencoded",
+			authorization: "Basic " + basic,
+		},
+		{
+			name:          "bearer wins over basic",
+			r:             Request{Method: http.MethodGet, URL: "https://example.com", BearerToken: "abc", BasicToken: basic},
+			method:        http.MethodGet,
+			contentType:   "application/json",
+			authorization: "Bearer abc",
+		},
+		{
+			name:        "gzip",
+			r:           Request{URL: "https://example.com", Body: []byte(strings.Repeat("a", MinGzipSize)), Gzip: true},
+			method:      http.MethodPost,
+			contentType: "application/json",
+			encoding:    "gzip",
+		},
+		{
+			name:        "small body not gzipped",
+			r:           Request{URL: "https://example.com", Body: []byte(`{}`), Gzip: true},
+			method:      http.MethodPost,
+			contentType: "application/json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := NewRequest(context.Background(), tt.r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if req.Method != tt.method {
+				t.Errorf("got method %s, want %s", req.Method, tt.method)
+			}
+			if got := req.Header.Get("Content-Type"); got != tt.contentType {
+				t.Errorf("got Content-Type %q, want %q", got, tt.contentType)
+			}
+			if got := req.Header.Get("Authorization"); got != tt.authorization {
+				t.Errorf("got Authorization %q, want %q", got, tt.authorization)
+			}
+			if got := req.Header.Get("Content-Encoding"); got != tt.encoding {
+				t.Errorf("got Content-Encoding %q, want %q", got, tt.encoding)
+			}
+		})
+	}
+}
+
+func TestNewRequestEndpoint(t *testing.T) {
+	req, err := NewRequest(context.Background(), Request{Endpoint: "pickup", URL: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := EndpointFromContext(req.Context()); got != "pickup" {
+		t.Errorf("got endpoint %q, want pickup", got)
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	bodies := []struct {
+		name string
+		body []byte
+	}{
+		{"json", []byte(`{"code":"200","transactionTimestamp":1602000000000,"data":{"pickupId":"a1b2c3","confirmationNbr":"CHI123456"}}`)},
+		{"fault", []byte(`<am:fault xmlns:am="http://wso2.org/apimanager"><am:code>900901</am:code><am:message>Unauthorized</am:message><am:description>Invalid Credentials</am:description></am:fault>`)},
+		{"html", []byte(`<!DOCTYPE html><html><body><h1>502 Bad Gateway</h1></body></html>`)},
+	}
+
+	for _, bb := range bodies {
+		b.Run(bb.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var v map[string]interface{}
+				Decode(bb.body, &v)
+			}
+		})
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	f.Add([]byte(`{"access_token":"abc","expires_in":43200}`))
+	f.Add([]byte(`<am:fault xmlns:am="http://wso2.org/apimanager"><am:code>900901</am:code><am:description>Invalid Credentials</am:description></am:fault>`))
+	f.Add([]byte(`<!DOCTYPE html><html><body>502 Bad Gateway</body></html>`))
+	f.Add([]byte(`{"access_token":"ab`))
+	f.Add([]byte(`<fault><code>`))
+	f.Add([]byte(``))
+	f.Add([]byte("\xff\xfe<html>"))
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		var token TokenResponse
+		err := Decode(body, &token)
+
+		if err == nil {
+			if sniff(bytes.TrimSpace(body)) != contentJSON {
+				t.Fatalf("got no error for a body that isn't json: %q", body)
+			}
+			return
+		}
+
+		var fault *Fault
+		var resErr *ResponseError
+		if !errors.As(err, &fault) && !errors.As(err, &resErr) {
+			t.Fatalf("got %T %v, want a *Fault or *ResponseError", err, err)
+		}
+	})
+}
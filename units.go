@@ -0,0 +1,41 @@
+package xpo
+
+import (
+	"math"
+)
+
+//conversion factors
+const (
+	poundsPerKilogram = 2.20462262185
+)
+
+//roundUp rounds up to the next whole number
+//The value is rounded to 3 decimal places first so float error (10.0000000001) doesn't round up a whole number.
+func roundUp(f float64) uint {
+	if f <= 0 {
+		return 0
+	}
+
+	f = math.Round(f*1000) / 1000
+	return uint(math.Ceil(f))
+}
+
+//Pounds returns a weight in pounds
+func Pounds(lb uint) Weight {
+	return Weight{
+		Weight: lb,
+	}
+}
+
+//Kilograms converts a weight in kilograms to the whole pounds XPO expects
+//The weight is rounded up so freight is never under declared, which gets it reweighed and rebilled.
+func Kilograms(kg float64) Weight {
+	return Weight{
+		Weight: roundUp(kg * poundsPerKilogram),
+	}
+}
+
+//Kilograms returns the weight in kilograms
+func (w Weight) Kilograms() float64 {
+	return float64(w.Weight) / poundsPerKilogram
+}
@@ -0,0 +1,370 @@
+package xpo
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//maxPkupItems is the most items XPO accepts on one pickup request
+const maxPkupItems = 50
+
+//country codes supported for addresses
+const (
+	CountryUS = "US"
+	CountryCA = "CA"
+	CountryMX = "MX"
+)
+
+//usStates are the state codes accepted for US addresses, including DC and territories
+var usStates = map[string]bool{
+	"AL": true, "AK": true, "AZ": true, "AR": true, "CA": true, "CO": true, "CT": true, "DE": true, "DC": true,
+	"FL": true, "GA": true, "HI": true, "ID": true, "IL": true, "IN": true, "IA": true, "KS": true, "KY": true,
+	"LA": true, "ME": true, "MD": true, "MA": true, "MI": true, "MN": true, "MS": true, "MO": true, "MT": true,
+	"NE": true, "NV": true, "NH": true, "NJ": true, "NM": true, "NY": true, "NC": true, "ND": true, "OH": true,
+	"OK": true, "OR": true, "PA": true, "RI": true, "SC": true, "SD": true, "TN": true, "TX": true, "UT": true,
+	"VT": true, "VA": true, "WA": true, "WV": true, "WI": true, "WY": true, "PR": true, "VI": true, "GU": true,
+}
+
+//caProvinces are the province and territory codes accepted for Canadian addresses
+var caProvinces = map[string]bool{
+	"AB": true, "BC": true, "MB": true, "NB": true, "NL": true, "NS": true, "NT": true, "NU": true, "ON": true,
+	"PE": true, "QC": true, "SK": true, "YT": true,
+}
+
+//mxStates are the two character state codes accepted for Mexican addresses
+//Mexico City is accepted as both CX and the older DF, and the State of Mexico as both EM and MX.
+var mxStates = map[string]bool{
+	"AG": true, "BC": true, "BS": true, "CM": true, "CS": true, "CH": true, "CO": true, "CL": true, "CX": true,
+	"DF": true, "DG": true, "GT": true, "GR": true, "HG": true, "JA": true, "EM": true, "MX": true, "MI": true,
+	"MO": true, "NA": true, "NL": true, "OA": true, "PU": true, "QT": true, "QR": true, "SL": true, "SI": true,
+	"SO": true, "TB": true, "TM": true, "TL": true, "VE": true, "YU": true, "ZA": true,
+}
+
+//postal code formats
+//Canadian postal codes never use D, F, I, O, Q, or U, and W and Z are never the first letter.
+var (
+	usZipRegex      = regexp.MustCompile(`^[0-9]{5}(-[0-9]{4})?$`)
+	mxPostalCdRegex = regexp.MustCompile(`^[0-9]{5}$`)
+	caPostalCdRegex = regexp.MustCompile(`^[ABCEGHJ-NPRSTVXY][0-9][ABCEGHJ-NPRSTV-Z] ?[0-9][ABCEGHJ-NPRSTV-Z][0-9]$`)
+)
+
+//ValidationError is returned when a pickup request has problems that XPO would reject it for
+type ValidationError struct {
+	Problems []string
+}
+
+//Error lists every problem found
+func (v *ValidationError) Error() string {
+	return "xpo - invalid request: " + strings.Join(v.Problems, "; ")
+}
+
+//add saves a problem
+func (v *ValidationError) add(problem string) {
+	v.Problems = append(v.Problems, problem)
+}
+
+//err returns the validation error if any problems were found, nil otherwise
+func (v *ValidationError) err() error {
+	if len(v.Problems) == 0 {
+		return nil
+	}
+
+	return v
+}
+
+//ValidStateCode checks if a state or province code is valid for the country
+func ValidStateCode(countryCd, stateCd string) bool {
+	stateCd = strings.ToUpper(strings.TrimSpace(stateCd))
+
+	switch strings.ToUpper(countryCd) {
+	case CountryUS:
+		return usStates[stateCd]
+	case CountryCA:
+		return caProvinces[stateCd]
+	case CountryMX:
+		return mxStates[stateCd]
+	default:
+		return false
+	}
+}
+
+//ValidPostalCode checks if a postal code is in the right format for the country
+//US zip codes can be 5 or 9 (ZIP+4) digits.  Canadian postal codes can be given with or without the space.
+//Mexican postal codes (codigo postal) are 5 digits.
+func ValidPostalCode(countryCd, postalCd string) bool {
+	postalCd = strings.ToUpper(strings.TrimSpace(postalCd))
+
+	switch strings.ToUpper(countryCd) {
+	case CountryUS:
+		return usZipRegex.MatchString(postalCd)
+	case CountryCA:
+		return caPostalCdRegex.MatchString(postalCd)
+	case CountryMX:
+		return mxPostalCdRegex.MatchString(postalCd)
+	default:
+		return false
+	}
+}
+
+//NormalizePostalCode cleans up a postal code into the format XPO uses
+//Canadian postal codes are uppercased with the space in the middle (A1A 1A1).  Anything that isn't a valid
+//postal code for the country is returned trimmed but otherwise unchanged.
+func NormalizePostalCode(countryCd, postalCd string) string {
+	postalCd = strings.ToUpper(strings.TrimSpace(postalCd))
+	if !ValidPostalCode(countryCd, postalCd) {
+		return postalCd
+	}
+
+	if strings.ToUpper(countryCd) == CountryCA {
+		postalCd = strings.Replace(postalCd, " ", "", -1)
+		return postalCd[:3] + " " + postalCd[3:]
+	}
+
+	return postalCd
+}
+
+//zip6 converts a US, Canadian, or Mexican postal code to the 6 character format used in PkupItem.DestZip6
+//US zip codes are the first 5 digits, Canadian postal codes have the space removed.
+func zip6(postalCd string) string {
+	postalCd = strings.ToUpper(strings.TrimSpace(postalCd))
+
+	if caPostalCdRegex.MatchString(postalCd) {
+		return strings.Replace(postalCd, " ", "", -1)
+	}
+	if usZipRegex.MatchString(postalCd) {
+		return postalCd[:5]
+	}
+
+	return postalCd
+}
+
+//normalize cleans up postal codes before a request is validated and sent
+func (pri *PickupRqstInfo) normalize() {
+	pri.Shipper.PostalCd = NormalizePostalCode(pri.Shipper.CountryCd, pri.Shipper.PostalCd)
+
+	for i := range pri.PkupItem {
+		pri.PkupItem[i].DestZip6 = zip6(pri.PkupItem[i].DestZip6)
+		if len(pri.PkupItem[i].Hazmat) > 0 && !pri.PkupItem[i].LimitedQtyInd {
+			pri.PkupItem[i].HazmatInd = true
+		}
+		if !pri.PkupItem[i].FoodHandling.IsZero() {
+			pri.PkupItem[i].FoodInd = true
+		}
+		if pri.PkupItem[i].BulkLiquid.Gallons > bulkLiquidGallons {
+			pri.PkupItem[i].BulkLiquidInd = true
+		}
+		if r := pri.PkupItem[i].TempRange; !r.IsZero() {
+			pri.PkupItem[i].TempSensitiveInd = true
+			if r.Low > freezingF {
+				pri.PkupItem[i].FrzbleInd = true
+			}
+		}
+	}
+	return
+}
+
+//validClock checks for a 24 hour HH:MM time
+func validClock(s string) bool {
+	_, err := time.Parse("15:04", s)
+	return err == nil && len(s) == 5
+}
+
+//Validate checks a pickup request for problems XPO would reject it for
+//This checks required fields, that addresses are valid for their country (US, Canada, or Mexico), and that
+//any totals given match the items.  A *ValidationError listing every problem is returned.  RequestPickup
+//fills in the totals itself so they always match there.
+func (pri *PickupRqstInfo) Validate() error {
+	v := &ValidationError{}
+
+	if pri.PkupDate.IsZero() {
+		v.add("pickup date is required")
+	}
+	if pri.ReadyTime.IsZero() {
+		v.add("ready time is required")
+	}
+	if pri.CloseTime.IsZero() {
+		v.add("close time is required")
+	}
+	if !pri.ReadyTime.IsZero() && !pri.CloseTime.IsZero() && !pri.CloseTime.After(pri.ReadyTime.Time) {
+		v.add("close time must be after ready time")
+	}
+
+	if len(pri.PkupItem) == 0 {
+		v.add("at least one pickup item is required")
+	}
+	if len(pri.PkupItem) > maxPkupItems {
+		v.add("too many pickup items, XPO allows up to 50")
+	}
+
+	pri.Shipper.validate(v)
+
+	//only phones with a country code are checked since older code sends numbers in whatever format
+	phones := []struct {
+		who   string
+		phone Phone
+	}{
+		{"shipper", pri.Shipper.Phone},
+		{"requestor", pri.Requestor.Contact.Phone},
+		{"contact", pri.Contact.Phone},
+	}
+	for _, p := range phones {
+		if p.phone.CountryCd == "" {
+			continue
+		}
+		if err := p.phone.Validate(); err != nil {
+			v.add(p.who + " phone: " + err.Error())
+		}
+	}
+
+	hazmat := false
+	var pallets, pieces, weight uint
+	for i, item := range pri.PkupItem {
+		hazmat = hazmat || item.HazmatInd
+		pallets += item.PalletCnt
+		pieces += item.LoosePiecesCnt
+		weight += item.TotWeight.Weight
+
+		if item.TotWeight.Weight == 0 {
+			v.add("item " + strconv.Itoa(i+1) + ": weight is required")
+		}
+
+		//mexican postal codes are the same format as US zip codes so they pass the US check
+		if item.DestZip6 != "" && !ValidPostalCode(CountryUS, item.DestZip6) && !ValidPostalCode(CountryCA, item.DestZip6) {
+			v.add("item " + strconv.Itoa(i+1) + ": destination zip is not a valid US, Canadian, or Mexican postal code")
+		}
+
+		//limited quantities are exempt from full hazmat rules, declaring both gets the shipment charged as hazmat
+		if item.LimitedQtyInd && item.HazmatInd {
+			v.add("item " + strconv.Itoa(i+1) + ": limited quantity items should not also be marked hazmat")
+		}
+
+		if b := item.BulkLiquid; !b.IsZero() {
+			if b.Gallons == 0 {
+				v.add("item " + strconv.Itoa(i+1) + ": bulk liquid gallons are required")
+			} else if item.BulkLiquidInd && b.Gallons <= bulkLiquidGallons {
+				v.add("item " + strconv.Itoa(i+1) + ": bulk liquid is only for more than 119 gallons, got " + strconv.FormatUint(uint64(b.Gallons), 10))
+			}
+		}
+
+		if r := item.TempRange; !r.IsZero() {
+			if r.High < r.Low {
+				v.add("item " + strconv.Itoa(i+1) + ": temperature range high is below low")
+			}
+			if item.FrzbleInd && r.Low <= freezingF {
+				v.add("item " + strconv.Itoa(i+1) + ": protect from freezing is set but the temperature range goes down to " + strconv.Itoa(r.Low) + "F")
+			}
+		}
+
+		for _, h := range item.Hazmat {
+			h.validate("item "+strconv.Itoa(i+1)+": ", v)
+		}
+	}
+
+	if hazmat {
+		pri.HazmatEmergency.validate(v)
+	}
+
+	//required notes are added first so this only happens if they are too long together
+	dropped := pri.remarks().Dropped()
+	for _, n := range pri.requiredRemarks() {
+		for _, d := range dropped {
+			if d == strings.TrimSpace(n) {
+				v.add("remarks: required note does not fit: " + n)
+			}
+		}
+	}
+
+	//totals are optional here, when given they have to add up or XPO plans the wrong size truck
+	if pri.TotPalletCnt != 0 && pri.TotPalletCnt != pallets {
+		v.add("total pallet count " + strconv.FormatUint(uint64(pri.TotPalletCnt), 10) + " does not match the items' " + strconv.FormatUint(uint64(pallets), 10))
+	}
+	if pri.TotLoosePieceCnt != 0 && pri.TotLoosePieceCnt != pieces {
+		v.add("total loose piece count " + strconv.FormatUint(uint64(pri.TotLoosePieceCnt), 10) + " does not match the items' " + strconv.FormatUint(uint64(pieces), 10))
+	}
+	if pri.TotWeight.Weight != 0 && pri.TotWeight.Weight != weight {
+		v.add("total weight " + strconv.FormatUint(uint64(pri.TotWeight.Weight), 10) + " does not match the items' " + strconv.FormatUint(uint64(weight), 10))
+	}
+
+	return v.err()
+}
+
+//validate checks the shipper's address
+func (s Shipper) validate(v *ValidationError) {
+	if s.AddressLine1 == "" {
+		v.add("shipper address is required")
+	}
+	if s.CityName == "" {
+		v.add("shipper city is required")
+	}
+	if s.DockHours != (DockHours{}) && (!validClock(s.DockHours.Open) || !validClock(s.DockHours.Close)) {
+		v.add("shipper dock hours must be given as HH:MM-HH:MM")
+	}
+
+	switch strings.ToUpper(s.CountryCd) {
+	case CountryUS, CountryCA, CountryMX:
+	case "":
+		v.add("shipper country is required")
+		return
+	default:
+		v.add("shipper country " + s.CountryCd + " is not supported")
+		return
+	}
+
+	if !ValidStateCode(s.CountryCd, s.StateCd) {
+		v.add("shipper state " + s.StateCd + " is not valid for country " + s.CountryCd)
+	}
+	if s.PostalCd != "" && !ValidPostalCode(s.CountryCd, s.PostalCd) {
+		v.add("shipper postal code " + s.PostalCd + " is not valid for country " + s.CountryCd)
+	}
+	return
+}
+
+//weights that need a person to look at a pickup before it is booked
+//These are conservative, a terminal may handle more, but freight over them often gets refused without notice.
+const (
+	heavyPieceLbs = 5000  //a single piece this heavy may need special equipment to load
+	permitLoadLbs = 45000 //about the most freight a standard trailer can legally carry without a permit
+	volumeLoadLbs = 20000 //shipments this heavy are usually volume loads that are quoted separately from LTL
+)
+
+//Warnings returns things about a pickup request that XPO may accept but that should be reviewed by a person
+//before booking, like pieces or loads that are too heavy for a normal LTL pickup or remarks that didn't fit.  Unlike Validate, these
+//don't stop the pickup from being requested.  Call this after the totals are filled in, RequestPickup and
+//BuildPickupRequest do that.  RequestPickup logs these and returns them on the response's ReviewWarnings.
+func (pri *PickupRqstInfo) Warnings() (warnings []string) {
+	total := pri.TotWeight.Weight
+	if total == 0 {
+		for _, item := range pri.PkupItem {
+			total += item.TotWeight.Weight
+		}
+	}
+
+	switch {
+	case total > permitLoadLbs && !pri.PermitLoadInd:
+		warnings = append(warnings, "total weight "+strconv.FormatUint(uint64(total), 10)+" lbs likely needs an overweight permit but PermitLoadInd is not set")
+	case total > permitLoadLbs:
+		warnings = append(warnings, "overweight permit load, arrange the permit with XPO before pickup")
+	case total > volumeLoadLbs:
+		warnings = append(warnings, "total weight "+strconv.FormatUint(uint64(total), 10)+" lbs is likely a volume load, XPO may not accept it as LTL")
+	}
+
+	if dropped := pri.remarks().Dropped(); len(dropped) > 0 {
+		warnings = append(warnings, "remarks are too long, left out: "+strings.Join(dropped, remarksSeparator))
+	}
+
+	for i, item := range pri.PkupItem {
+		if dropped := item.remarks().Dropped(); len(dropped) > 0 {
+			warnings = append(warnings, "item "+strconv.Itoa(i+1)+": remarks are too long, left out: "+strings.Join(dropped, remarksSeparator))
+		}
+		if item.HeaviestPiece.Weight > heavyPieceLbs {
+			warnings = append(warnings, "item "+strconv.Itoa(i+1)+": a single piece weighs "+strconv.FormatUint(uint64(item.HeaviestPiece.Weight), 10)+" lbs and may need special equipment")
+		}
+		if item.TotWeight.Weight > 0 && item.HeaviestPiece.Weight > item.TotWeight.Weight {
+			warnings = append(warnings, "item "+strconv.Itoa(i+1)+": heaviest piece weighs more than the whole item")
+		}
+	}
+
+	return
+}
@@ -0,0 +1,164 @@
+package xpo
+
+import (
+	"testing"
+)
+
+func BenchmarkValidate(b *testing.B) {
+	pri := testPickup()
+	if err := pri.prepare(); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := pri.Validate(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestValidStateCode(t *testing.T) {
+	tests := []struct {
+		country string
+		state   string
+		want    bool
+	}{
+		{CountryUS, "IL", true},
+		{CountryUS, "il", true},
+		{CountryUS, " NY ", true},
+		{CountryUS, "DC", true},
+		{CountryUS, "PR", true},
+		{CountryUS, "CA", true}, //California, not Canada
+		{CountryUS, "ON", false},
+		{CountryUS, "QC", false},
+		{CountryUS, "BC", false},
+		{CountryCA, "ON", true},
+		{CountryCA, "qc", true},
+		{CountryCA, "YT", true},
+		{CountryCA, "IL", false},
+		{CountryCA, "NY", false},
+		{CountryCA, "CA", false},
+		{CountryMX, "JA", true},
+		{CountryMX, "DF", true},
+		{CountryMX, "IL", false},
+		{"us", "IL", true},
+		{"", "IL", false},
+		{"GB", "LN", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidStateCode(tt.country, tt.state); got != tt.want {
+			t.Errorf("ValidStateCode(%q, %q) = %v, want %v", tt.country, tt.state, got, tt.want)
+		}
+	}
+}
+
+func TestValidPostalCode(t *testing.T) {
+	tests := []struct {
+		country string
+		postal  string
+		want    bool
+	}{
+		{CountryUS, "60601", true},
+		{CountryUS, "60601-1234", true},
+		{CountryUS, " 60601 ", true},
+		{CountryUS, "6060", false},
+		{CountryUS, "606011234", false},
+		{CountryUS, "60601-123", false},
+		{CountryUS, "M5V 2T6", false},
+		{CountryCA, "M5V 2T6", true},
+		{CountryCA, "M5V2T6", true},
+		{CountryCA, "m5v 2t6", true},
+		{CountryCA, "W5V 2T6", false}, //W is never the first letter
+		{CountryCA, "D5V 2T6", false}, //D is never used
+		{CountryCA, "M5V 2T", false},
+		{CountryCA, "60601", false},
+		{CountryMX, "06600", true},
+		{CountryMX, "0660", false},
+		{CountryMX, "06600-1234", false},
+		{"", "60601", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidPostalCode(tt.country, tt.postal); got != tt.want {
+			t.Errorf("ValidPostalCode(%q, %q) = %v, want %v", tt.country, tt.postal, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizePostalCode(t *testing.T) {
+	tests := []struct {
+		country string
+		postal  string
+		want    string
+	}{
+		{CountryUS, "60601", "60601"},
+		{CountryUS, " 60601-1234 ", "60601-1234"},
+		{CountryCA, "m5v2t6", "M5V 2T6"},
+		{CountryCA, " M5V 2T6 ", "M5V 2T6"},
+		{CountryCA, "m5v 2t6", "M5V 2T6"},
+		{CountryCA, "not valid", "NOT VALID"},
+		{CountryMX, "06600", "06600"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizePostalCode(tt.country, tt.postal); got != tt.want {
+			t.Errorf("NormalizePostalCode(%q, %q) = %q, want %q", tt.country, tt.postal, got, tt.want)
+		}
+	}
+}
+
+func TestZip6(t *testing.T) {
+	tests := []struct {
+		postal string
+		want   string
+	}{
+		{"60601", "60601"},
+		{"60601-1234", "60601"},
+		{" 60601 ", "60601"},
+		{"M5V 2T6", "M5V2T6"},
+		{"m5v2t6", "M5V2T6"},
+		{"06600", "06600"},
+		{"garbage", "GARBAGE"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := zip6(tt.postal); got != tt.want {
+			t.Errorf("zip6(%q) = %q, want %q", tt.postal, got, tt.want)
+		}
+	}
+}
+
+func TestValidateShipperCountry(t *testing.T) {
+	tests := []struct {
+		name    string
+		country string
+		state   string
+		postal  string
+		wantErr bool
+	}{
+		{"us", CountryUS, "IL", "60601", false},
+		{"canada", CountryCA, "ON", "M5V 2T6", false},
+		{"us state in canada", CountryCA, "IL", "M5V 2T6", true},
+		{"canadian province in us", CountryUS, "ON", "60601", true},
+		{"canadian postal code in us", CountryUS, "IL", "M5V 2T6", true},
+		{"us zip in canada", CountryCA, "ON", "60601", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pri := testPickup()
+			pri.Shipper.CountryCd = tt.country
+			pri.Shipper.StateCd = tt.state
+			pri.Shipper.PostalCd = tt.postal
+
+			err := pri.prepare()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+/*Package vcr records exchanges with the XPO API to cassette files and replays them, so endpoint code can be
+tested without credentials.
+
+Record once against XPO's test mode with real credentials:
+
+	rec, err := vcr.New("testdata/pickup.json", vcr.ModeRecord, nil)
+	client, err := xpo.NewClient(xpo.Config{..., HTTPClient: &http.Client{Transport: rec}})
+	//make requests
+	err = rec.Save()
+
+Then replay in tests with vcr.ModeReplay and any credentials.  Secrets (the Authorization header, the
+username and password in the token request, and the tokens in the token response) are scrubbed before
+anything is saved.
+*/
+package vcr
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+//Mode is whether a recorder is capturing or playing back
+type Mode int
+
+//modes
+const (
+	ModeRecord Mode = iota //requests go to XPO and are saved
+	ModeReplay             //requests are answered from the cassette, nothing goes to XPO
+)
+
+//redacted replaces scrubbed secrets
+const redacted = "REDACTED"
+
+//secretFields are form and json fields that are scrubbed from recordings
+var secretFields = []string{"username", "password", "access_token", "refresh_token"}
+
+//Interaction is one request and its response
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+//Request is a recorded request
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+//Response is a recorded response
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+//Cassette is the file interactions are saved to
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+//Recorder is an http.RoundTripper that records or replays interactions
+type Recorder struct {
+	mode Mode
+	path string
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool //interactions already replayed
+}
+
+//New creates a recorder for the cassette file at path
+//In replay mode the cassette is loaded right away.  next is the RoundTripper used to reach XPO when
+//recording, it defaults to http.DefaultTransport.
+func New(path string, mode Mode, next http.RoundTripper) (r *Recorder, err error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	r = &Recorder{
+		mode: mode,
+		path: path,
+		next: next,
+	}
+
+	if mode == ModeReplay {
+		var b []byte
+		b, err = ioutil.ReadFile(path)
+		if err != nil {
+			err = errors.Wrap(err, "vcr.New - could not read cassette")
+			return
+		}
+
+		err = json.Unmarshal(b, &r.cassette)
+		if err != nil {
+			err = errors.Wrap(err, "vcr.New - could not unmarshal cassette")
+			return
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+
+	return
+}
+
+//RoundTrip records or replays a request
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "vcr.RoundTrip - could not read request body")
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req)
+	}
+
+	return r.record(req, reqBody)
+}
+
+//record sends the request on and saves the scrubbed exchange
+func (r *Recorder) record(req *http.Request, reqBody []byte) (*http.Response, error) {
+	res, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "vcr.RoundTrip - could not read response body")
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+
+	i := Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: scrubHeader(req.Header),
+			Body:   scrubBody(reqBody),
+		},
+		Response: Response{
+			StatusCode: res.StatusCode,
+			Header:     scrubHeader(res.Header),
+			Body:       scrubBody(resBody),
+		},
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, i)
+	r.mu.Unlock()
+
+	return res, nil
+}
+
+//replay answers a request with the next unused recorded interaction for the same method and url
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for idx, i := range r.cassette.Interactions {
+		if r.used[idx] || i.Request.Method != req.Method || i.Request.URL != req.URL.String() {
+			continue
+		}
+		r.used[idx] = true
+
+		res := &http.Response{
+			Status:        http.StatusText(i.Response.StatusCode),
+			StatusCode:    i.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        i.Response.Header,
+			Body:          ioutil.NopCloser(strings.NewReader(i.Response.Body)),
+			ContentLength: int64(len(i.Response.Body)),
+			Request:       req,
+		}
+		if res.Header == nil {
+			res.Header = http.Header{}
+		}
+		return res, nil
+	}
+
+	return nil, errors.New("vcr.RoundTrip - no recorded interaction for " + req.Method + " " + req.URL.String())
+}
+
+//Save writes the recorded interactions to the cassette file
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "vcr.Save - could not marshal cassette")
+	}
+
+	err = ioutil.WriteFile(r.path, b, 0644)
+	if err != nil {
+		return errors.Wrap(err, "vcr.Save - could not write cassette")
+	}
+
+	return nil
+}
+
+//scrubHeader copies a header with the Authorization and cookies removed
+func scrubHeader(h http.Header) http.Header {
+	out := h.Clone()
+	for _, k := range []string{"Authorization", "Cookie", "Set-Cookie"} {
+		if out.Get(k) != "" {
+			out.Set(k, redacted)
+		}
+	}
+
+	return out
+}
+
+//scrubBody removes secrets from a form or json body
+func scrubBody(b []byte) string {
+	trimmed := bytes.TrimSpace(b)
+
+	//json, like the token response
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		var m map[string]interface{}
+		if err := json.Unmarshal(trimmed, &m); err == nil {
+			changed := false
+			for _, f := range secretFields {
+				if _, ok := m[f]; ok {
+					m[f] = redacted
+					changed = true
+				}
+			}
+			if !changed {
+				return string(b)
+			}
+
+			out, _ := json.Marshal(m)
+			return string(out)
+		}
+		return string(b)
+	}
+
+	//form, like the token request
+	if v, err := url.ParseQuery(string(trimmed)); err == nil && len(v) > 0 && !bytes.HasPrefix(trimmed, []byte("<")) {
+		changed := false
+		for _, f := range secretFields {
+			if v.Get(f) != "" {
+				v.Set(f, redacted)
+				changed = true
+			}
+		}
+		if changed {
+			return v.Encode()
+		}
+	}
+
+	return string(b)
+}
@@ -0,0 +1,161 @@
+package vcr_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coreymgilmore/xpologistics"
+	"github.com/coreymgilmore/xpologistics/vcr"
+)
+
+//testPickup returns the pickup that was requested when testdata/pickup.json was recorded
+func testPickup() *xpo.PickupRqstInfo {
+	day := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	contact := xpo.Contact{
+		CompanyName: "Acme Supply",
+		Email:       xpo.Email{EmailAddr: "shipping@acme.example"},
+		FullName:    "Pat Smith",
+		Phone:       xpo.Phone{PhoneNbr: "312-5551212"},
+	}
+
+	return &xpo.PickupRqstInfo{
+		PkupDate:  xpo.NewDate(day),
+		ReadyTime: xpo.NewTime(day.Add(14 * time.Hour)),
+		CloseTime: xpo.NewTime(day.Add(17 * time.Hour)),
+		PkupItem: []xpo.PkupItem{
+			{TotWeight: xpo.Weight{Weight: 1200}, DestZip6: "10001", PalletCnt: 2},
+		},
+		Shipper: xpo.Shipper{
+			AddressLine1: "100 Industrial Dr",
+			CityName:     "Chicago",
+			StateCd:      "IL",
+			CountryCd:    xpo.CountryUS,
+			Name:         "Acme Supply",
+			PostalCd:     "60601",
+			Phone:        xpo.Phone{PhoneNbr: "312-5551212"},
+		},
+		Requestor: xpo.Requestor{Contact: contact, RoleCd: xpo.RoleShipper},
+		Contact:   contact,
+		Remarks:   "call before arrival",
+	}
+}
+
+//replayClient returns a client that is answered from a cassette
+func replayClient(t *testing.T, path string) *xpo.Client {
+	rec, err := vcr.New(path, vcr.ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := xpo.NewClient(xpo.Config{
+		Username:    "user",
+		Password:    "password",
+		AccessToken: "access",
+		HTTPClient:  &http.Client{Transport: rec},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func TestReplayRequestPickup(t *testing.T) {
+	c := replayClient(t, filepath.Join("testdata", "pickup.json"))
+
+	res, err := c.RequestPickup(context.Background(), testPickup())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Data.ConfirmationNbr != "CHI123456" || res.Data.PickupID != "9e2f7c1a" {
+		t.Errorf("got confirmation %s and pickup id %s", res.Data.ConfirmationNbr, res.Data.PickupID)
+	}
+	if res.TransactionTimestamp.IsZero() {
+		t.Error("transaction timestamp not decoded")
+	}
+
+	//the cassette only has one pickup, a second request must not go anywhere
+	_, err = c.RequestPickup(context.Background(), testPickup())
+	if err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+		t.Errorf("got %v, want no recorded interaction", err)
+	}
+}
+
+func TestReplayPing(t *testing.T) {
+	c := replayClient(t, filepath.Join("testdata", "pickup.json"))
+
+	err := c.Ping(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+//fakeXPO answers the token and pickup endpoints like XPO does
+type fakeXPO struct{}
+
+func (fakeXPO) RoundTrip(req *http.Request) (*http.Response, error) {
+	body := `{"code":"200","transactionTimestamp":1791986400000,"data":{"pickupId":"9e2f7c1a","confirmationNbr":"CHI123456"}}`
+	if strings.HasSuffix(req.URL.Path, "/token") {
+		body = `{"access_token":"real-bearer","refresh_token":"real-refresh","scope":"default","token_type":"Bearer","expires_in":43200}`
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestRecordScrubsSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	rec, err := vcr.New(path, vcr.ModeRecord, fakeXPO{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := xpo.NewClient(xpo.Config{
+		Username:    "real-user",
+		Password:    "real-password",
+		AccessToken: "real-access",
+		HTTPClient:  &http.Client{Transport: rec},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.RequestPickup(context.Background(), testPickup())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = rec.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"real-user", "real-password", "real-access", "real-bearer", "real-refresh"} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("cassette contains %s", secret)
+		}
+	}
+
+	//what was recorded replays the same
+	res, err := replayClient(t, path).RequestPickup(context.Background(), testPickup())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Data.ConfirmationNbr != "CHI123456" {
+		t.Errorf("got confirmation %s from the recorded cassette", res.Data.ConfirmationNbr)
+	}
+}
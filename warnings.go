@@ -0,0 +1,63 @@
+package xpo
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+//Warning is a note XPO returned along with a successful response, like an adjusted ready time
+//XPO isn't consistent about the shape of warnings, they come as plain strings or as objects with a code and
+//message, so both are accepted.
+type Warning struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+	Field   string `json:"fieldName,omitempty"` //the request field the warning is about, if given
+}
+
+//String formats the warning for logging
+func (w Warning) String() string {
+	s := w.Message
+	if w.Field != "" {
+		s = w.Field + ": " + s
+	}
+	if w.Code != "" {
+		s = w.Code + " " + s
+	}
+	return s
+}
+
+//UnmarshalJSON reads a warning given as either a string or an object
+func (w *Warning) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '"' {
+		w.Code = ""
+		w.Field = ""
+		return json.Unmarshal(b, &w.Message)
+	}
+
+	//XPO has used a few names for the same thing
+	var raw struct {
+		Code        json.RawMessage `json:"code"`
+		ErrorCode   string          `json:"errorCode"`
+		Message     string          `json:"message"`
+		Description string          `json:"description"`
+		FieldName   string          `json:"fieldName"`
+	}
+	err := json.Unmarshal(b, &raw)
+	if err != nil {
+		return err
+	}
+
+	//codes come as numbers or strings
+	w.Code = strings.Trim(string(raw.Code), `"`)
+	if w.Code == "" || w.Code == "null" {
+		w.Code = raw.ErrorCode
+	}
+	w.Message = raw.Message
+	if w.Message == "" {
+		w.Message = raw.Description
+	}
+	w.Field = raw.FieldName
+	return nil
+}
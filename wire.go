@@ -0,0 +1,172 @@
+package xpo
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+//the wire types are the pickup request exactly as XPO takes it
+//Our types have fields XPO has no place for, like extra contacts and hazmat details, and are json encoded with
+//every field so pickups can be saved and loaded again without losing anything.  PickupRequest.MarshalJSON
+//converts to these types, folding those fields into the remarks, to build what is sent to XPO.
+
+type wirePickupRequest struct {
+	PickupRqstInfo wirePickup `json:"pickupRqstInfo"`
+}
+
+type wirePickup struct {
+	PkupDate           Date          `json:"pkupDate"`
+	ReadyTime          Time          `json:"readyTime"`
+	CloseTime          Time          `json:"closeTime"`
+	PkupItem           []wireItem    `json:"pkupItem"`
+	SpecialEquipmentCd string        `json:"specialEquipmentCd"`
+	InsidePkupInd      bool          `json:"insidePkupInd"`
+	Shipper            wireShipper   `json:"shipper"`
+	Requestor          wireRequestor `json:"requestor"`
+	Contact            wireContact   `json:"contact"`
+	Remarks            string        `json:"remarks"`
+	TotPalletCnt       uint          `json:"totPalletCnt"`
+	TotLoosePieceCnt   uint          `json:"totLoosePieceCnt"`
+	TotWeight          Weight        `json:"totWeight"`
+}
+
+type wireItem struct {
+	TotWeight      Weight `json:"totWeight"`
+	DestZip6       string `json:"destZip6"`
+	LoosePiecesCnt uint   `json:"loosePiecesCnt"`
+	PalletCnt      uint   `json:"palletCnt"`
+	GarntInd       bool   `json:"garntInd"`
+	HazmatInd      bool   `json:"hazmatInd"`
+	FrzbleInd      bool   `json:"frzbleInd"`
+	HolDlvrInd     bool   `json:"holDlvrInd"`
+	FoodInd        bool   `json:"foodInd"`
+	BulkLiquidInd  bool   `json:"bulkLiquidInd"`
+	Remarks        string `json:"remarks"`
+}
+
+type wireShipper struct {
+	AddressLine1 string    `json:"addressLine1"`
+	CityName     string    `json:"cityName"`
+	StateCd      string    `json:"stateCd"`
+	CountryCd    string    `json:"countryCd"`
+	Name         string    `json:"name"`
+	AddressLine2 string    `json:"addressLine2"`
+	PostalCd     string    `json:"postalCd"`
+	Phone        wirePhone `json:"phone"`
+}
+
+type wireRequestor struct {
+	Contact wireContact `json:"contact"`
+	RoleCd  string      `json:"roleCd"`
+}
+
+type wireContact struct {
+	CompanyName string    `json:"companyName"`
+	Email       Email     `json:"email"`
+	FullName    string    `json:"fullName"`
+	Phone       wirePhone `json:"phone"`
+}
+
+type wirePhone struct {
+	PhoneNbr string `json:"phoneNbr"`
+}
+
+//MarshalJSON builds the pickup request XPO expects from our fields
+//XPO only takes a single contact and a free text remarks field, so extra info is mapped into those.  If no
+//Contact is set, the first of Contacts is used as the contact.  See remarks for what is added to the remarks.
+//Handling flags XPO has no field for are added to each item's remarks.
+func (pr PickupRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(wirePickupRequest{
+		PickupRqstInfo: pr.PickupRqstInfo.wire(),
+	})
+}
+
+//wire converts a pickup to what is sent to XPO
+func (pri PickupRqstInfo) wire() wirePickup {
+	contact := pri.Contact
+	if contact == (Contact{}) && len(pri.Contacts) > 0 {
+		contact = pri.Contacts[0].Contact
+	}
+
+	items := make([]wireItem, 0, len(pri.PkupItem))
+	for _, i := range pri.PkupItem {
+		items = append(items, i.wire())
+	}
+
+	return wirePickup{
+		PkupDate:           pri.PkupDate,
+		ReadyTime:          pri.ReadyTime,
+		CloseTime:          pri.CloseTime,
+		PkupItem:           items,
+		SpecialEquipmentCd: pri.SpecialEquipmentCd,
+		InsidePkupInd:      pri.InsidePkupInd,
+		Shipper:            pri.Shipper.wire(),
+		Requestor: wireRequestor{
+			Contact: pri.Requestor.Contact.wire(),
+			RoleCd:  pri.Requestor.RoleCd,
+		},
+		Contact:          contact.wire(),
+		Remarks:          pri.remarks().String(),
+		TotPalletCnt:     pri.TotPalletCnt,
+		TotLoosePieceCnt: pri.TotLoosePieceCnt,
+		TotWeight:        pri.TotWeight,
+	}
+}
+
+//wire converts an item to what is sent to XPO
+func (i PkupItem) wire() wireItem {
+	return wireItem{
+		TotWeight:      i.TotWeight,
+		DestZip6:       i.DestZip6,
+		LoosePiecesCnt: i.LoosePiecesCnt,
+		PalletCnt:      i.PalletCnt,
+		GarntInd:       i.GarntInd,
+		HazmatInd:      i.HazmatInd,
+		FrzbleInd:      i.FrzbleInd,
+		HolDlvrInd:     i.HolDlvrInd,
+		FoodInd:        i.FoodInd,
+		BulkLiquidInd:  i.BulkLiquidInd,
+		Remarks:        i.remarks().String(),
+	}
+}
+
+//wire converts a shipper to what is sent to XPO
+func (s Shipper) wire() wireShipper {
+	return wireShipper{
+		AddressLine1: s.AddressLine1,
+		CityName:     s.CityName,
+		StateCd:      s.StateCd,
+		CountryCd:    s.CountryCd,
+		Name:         s.Name,
+		AddressLine2: s.AddressLine2,
+		PostalCd:     s.PostalCd,
+		Phone:        s.Phone.wire(),
+	}
+}
+
+//wire converts a contact to what is sent to XPO
+func (c Contact) wire() wireContact {
+	return wireContact{
+		CompanyName: c.CompanyName,
+		Email:       c.Email,
+		FullName:    c.FullName,
+		Phone:       c.Phone.wire(),
+	}
+}
+
+//wire converts a phone to what is sent to XPO
+//XPO only takes the number so a dialing code other than US/Canada's is added in front of it, ex: +52 5512345678,
+//and an extension after it, ex: 312-5551212 x204.
+func (p Phone) wire() wirePhone {
+	n := p.PhoneNbr
+	if c := digits(p.CountryCd); c != "" && c != DialingCodeNANP && n != "" {
+		n = "+" + c + " " + n
+	}
+	if e := strings.TrimSpace(p.Extension); e != "" && n != "" {
+		n += " x" + e
+	}
+
+	return wirePhone{
+		PhoneNbr: n,
+	}
+}
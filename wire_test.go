@@ -0,0 +1,71 @@
+package xpo
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func BenchmarkMarshalPickup(b *testing.B) {
+	pri := testPickup()
+	if err := pri.prepare(); err != nil {
+		b.Fatal(err)
+	}
+	pr := PickupRequest{PickupRqstInfo: pri}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := json.Marshal(pr); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestPhoneWire(t *testing.T) {
+	tests := []struct {
+		phone Phone
+		want  string
+	}{
+		{Phone{PhoneNbr: "312-5551212"}, "312-5551212"},
+		{Phone{CountryCd: DialingCodeNANP, PhoneNbr: "312-5551212"}, "312-5551212"},
+		{Phone{CountryCd: DialingCodeNANP, PhoneNbr: "312-5551212", Extension: "204"}, "312-5551212 x204"},
+		{Phone{CountryCd: DialingCodeMexico, PhoneNbr: "5512345678"}, "+52 5512345678"},
+		{Phone{CountryCd: DialingCodeMexico, PhoneNbr: "5512345678", Extension: "12"}, "+52 5512345678 x12"},
+		{Phone{CountryCd: DialingCodeMexico, Extension: "12"}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := tt.phone.wire().PhoneNbr; got != tt.want {
+			t.Errorf("%+v sent as %q, want %q", tt.phone, got, tt.want)
+		}
+	}
+}
+
+func TestMarshalPickupOnlyXPOFields(t *testing.T) {
+	pri := testPickup()
+	pri.Shipper.Phone = Phone{CountryCd: DialingCodeNANP, PhoneNbr: "312-5551212", Extension: "204"}
+	if err := pri.prepare(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(PickupRequest{PickupRqstInfo: pri})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]map[string]interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	phone := got["pickupRqstInfo"]["shipper"].(map[string]interface{})["phone"].(map[string]interface{})
+	if len(phone) != 1 || phone["phoneNbr"] != "312-5551212 x204" {
+		t.Errorf("got shipper phone %v, want only phoneNbr with the extension", phone)
+	}
+
+	for _, field := range []string{"contacts", "hazmatEmergency", "permitLoadInd", "accounting", "metadata"} {
+		if _, ok := got["pickupRqstInfo"][field]; ok {
+			t.Errorf("%s sent to XPO", field)
+		}
+	}
+}
@@ -10,58 +10,48 @@ got the "bearer" token).
 
 Currently this package can perform:
 - pickup requests
+- shipment tracking
 
 To create a pickup request:
-- Set test or production mode (SetProductionMode()).
+- Create a client (NewClient()) with your credentials and mode (ModeTest or ModeProduction).
 - Set your shipper (Shipper{}) and requestor (Requestor{}) info.
 - Set shipment details (PkupItem{}).
-- Request the pickup (RequestPickup()).
+- Request the pickup (Client.RequestPickup()).
 - Check for any errors.
+
+To track shipments:
+- Create a client (NewClient()), a read only client (Config.ReadOnly) is fine.
+- Track one or many shipments by PRO number (Client.TrackByPRO()).
+- Check the error, current status, estimated delivery date, and events of each shipment (Results[Shipment]).
+- Optionally, watch shipments with a TrackingMonitor to be alerted when one stops moving (AlertStuck).
+
+The package level SetCredentials(), SetMode(), and PickupRqstInfo.RequestPickup() are kept, deprecated, for
+existing code.  They use a default client that is created on first use.  Some field types changed so existing
+code still needs updating to compile, see the README.
 */
 package xpo
 
 import (
-	"bytes"
-	"encoding/json"
-	"encoding/xml"
-	"io/ioutil"
-	"log"
-	"net/http"
-	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/coreymgilmore/xpologistics/transport"
 	"github.com/pkg/errors"
 )
 
 //api urls
+//the pickup url has the testMode query parameter added based on the client's mode
 const (
-	xpoTokenURL      = "https://api.ltl.xpo.com/token"
-	xpoProductionURL = "https://api.ltl.xpo.com/pickuprequest/1.0/cust-pickup-requests?testMode=N"
-	xpoTestURL       = "https://api.ltl.xpo.com/pickuprequest/1.0/cust-pickup-requests?testMode=Y"
+	xpoBaseURL   = "https://api.ltl.xpo.com"
+	xpoTokenURL  = transport.TokenURL
+	xpoPickupURL = xpoBaseURL + "/pickuprequest/1.0/cust-pickup-requests"
 )
 
-//xpoURL is se to the test URL by default
-//This is changed to the production URL when the SetProductionMode function is called
-//Forcing the developer to call the SetProductionMode function ensures the production URL is only used
-//when actually needed.
-var xpoURL = xpoTestURL
-
-//timeout is the default time we should wait for a reply from XPO
+//defaultTimeout is the default time we should wait for a reply from XPO
 //You may need to adjust this based on how slow connecting to XPO is for you.
 //10 seconds is overly long, but sometimes XPO is very slow.
-var timeout = time.Duration(10 * time.Second)
-
-//our xpo credentials
-//these must be set in SetCredentials() prior to making requests
-var (
-	//website login
-	username string
-	password string
-
-	//accessToken is the token we use to retrieve other tokens to make api calls
-	//This token should be kept secret and lasts until it is revoked.
-	accessToken string
-)
+const defaultTimeout = time.Duration(10 * time.Second)
 
 //role codes for what the requestor of the pickup is in relation to this shipment
 var (
@@ -71,7 +61,8 @@ var (
 )
 
 //PickupRequest is the main container struct for data sent to XPO to request a pickup
-//This is a single field with another container struct inside.  Why...who knows, ask XPO.
+//This is a single field with another container struct inside.  Why...who knows, ask XPO.  See MarshalJSON
+//for how our fields are mapped to what XPO takes.
 type PickupRequest struct {
 	PickupRqstInfo PickupRqstInfo `json:"pickupRqstInfo"`
 }
@@ -79,9 +70,9 @@ type PickupRequest struct {
 //PickupRqstInfo holds all the data on a pickup request
 type PickupRqstInfo struct {
 	//required
-	PkupDate  string     `json:"pkupDate"`  //YYYY-MM-DDTHH:MM:SS
-	ReadyTime string     `json:"readyTime"` //YYYY-MM-DDTHH:MM:SS
-	CloseTime string     `json:"closeTime"` //YYYY-MM-DDTHH:MM:SS
+	PkupDate  Date       `json:"pkupDate"`  //YYYY-MM-DDT00:00:00
+	ReadyTime Time       `json:"readyTime"` //YYYY-MM-DDTHH:MM:SS
+	CloseTime Time       `json:"closeTime"` //YYYY-MM-DDTHH:MM:SS
 	PkupItem  []PkupItem `json:"pkupItem"`  //items being picked up, up to 50
 
 	//optional
@@ -94,6 +85,15 @@ type PickupRqstInfo struct {
 	TotPalletCnt       uint      `json:"totPalletCnt"`
 	TotLoosePieceCnt   uint      `json:"totLoosePieceCnt"`
 	TotWeight          Weight    `json:"totWeight"`
+
+	//not sent to XPO as is, see PickupRequest.MarshalJSON
+	Contacts        []RoleContact          `json:"contacts,omitempty"`      //extra contacts, XPO only takes one so these are sent in the remarks
+	HazmatEmergency HazmatEmergencyContact `json:"hazmatEmergency"`         //required when any item is hazmat, sent in the remarks
+	PermitLoadInd   bool                   `json:"permitLoadInd,omitempty"` //shipment needs an overweight permit, sent in the remarks, see Warnings
+
+	//never sent to XPO
+	Accounting Accounting        `json:"accounting"`         //your own codes for allocating freight spend
+	Metadata   map[string]string `json:"metadata,omitempty"` //your own data, like order ids, kept with stored and queued pickups and copied to the response
 }
 
 //Shipper holds data on the shipper
@@ -101,14 +101,35 @@ type Shipper struct {
 	//required
 	AddressLine1 string `json:"addressLine1"`
 	CityName     string `json:"cityName"`
-	StateCd      string `json:"stateCd"`   //two character code
-	CountryCd    string `json:"countryCd"` //to char code
+	StateCd      string `json:"stateCd"`   //two character state or province code
+	CountryCd    string `json:"countryCd"` //two character code, US, CA, or MX
 
 	//optional
 	Name         string `json:"name"` //company name
 	AddressLine2 string `json:"addressLine2"`
-	PostalCd     string `json:"postalCd"`
+	PostalCd     string `json:"postalCd"` //zip code, or Canadian postal code as A1A 1A1
 	Phone        Phone  `json:"phone"`
+
+	//not sent to XPO as is, these are added to the pickup remarks
+	DockHours          DockHours `json:"dockHours"`
+	DockDoor           string    `json:"dockDoor,omitempty"`           //door number the driver should back into
+	DriverInstructions string    `json:"driverInstructions,omitempty"` //ex: check in at the guard shack
+}
+
+//DockHours is when the shipper's dock is open
+//Times are in 24 hour HH:MM format, local to the shipper.
+type DockHours struct {
+	Open  string
+	Close string
+}
+
+//String formats the dock hours for the pickup remarks
+func (d DockHours) String() string {
+	if d.Open == "" && d.Close == "" {
+		return ""
+	}
+
+	return "DOCK HOURS " + d.Open + "-" + d.Close
 }
 
 //Requestor holds data on who requested the pickup
@@ -132,9 +153,12 @@ type Email struct {
 }
 
 //Phone holds an phone number
-//why this is a separate struct...ask XPO
+//why this is a separate struct...ask XPO.  XPO only takes the number, CountryCd and Extension are added to it
+//when sent, see PickupRequest.MarshalJSON.
 type Phone struct {
-	PhoneNbr string `json:"phoneNbr"` //format is 999-999999
+	CountryCd string `json:"countryCd,omitempty"` //dialing code without the +, 1 for US/Canada, 52 for Mexico
+	PhoneNbr  string `json:"phoneNbr"`            //format is 999-9999999 for US/Canada, digits otherwise
+	Extension string `json:"extension,omitempty"`
 }
 
 //PkupItem is the good being picked up
@@ -143,7 +167,7 @@ type PkupItem struct {
 	TotWeight Weight `json:"totWeight"`
 
 	//optional
-	DestZip6       string `json:"destZip6"` //ship to zip/postal code
+	DestZip6       string `json:"destZip6"` //ship to zip/postal code, 5 digit zip or 6 character Canadian postal code
 	LoosePiecesCnt uint   `json:"loosePiecesCnt"`
 	PalletCnt      uint   `json:"palletCnt"`
 	GarntInd       bool   `json:"garntInd"` //guaranteed service
@@ -153,215 +177,182 @@ type PkupItem struct {
 	FoodInd        bool   `json:"foodInd"`       //food stuffs
 	BulkLiquidInd  bool   `json:"bulkLiquidInd"` //bulk liquid shipment greater than 119 US gallons
 	Remarks        string `json:"remarks"`       //random note for this pickup
+
+	//not sent to XPO as is, see PickupRequest.MarshalJSON
+	Hazmat        []HazmatDetail `json:"hazmat,omitempty"`        //checked when the pickup is validated, sets HazmatInd when given
+	LimitedQtyInd bool           `json:"limitedQtyInd,omitempty"` //limited quantity hazmat, noted in the remarks instead of setting HazmatInd
+	FoodHandling  FoodHandling   `json:"foodHandling"`            //sets FoodInd when given, noted in the remarks
+
+	//temperature sensitive freight that doesn't need a reefer, noted in the remarks
+	//A range that stays above freezing sets FrzbleInd.
+	TempSensitiveInd bool             `json:"tempSensitiveInd,omitempty"`
+	TempRange        TemperatureRange `json:"tempRange"` //optional, sets TempSensitiveInd when given
+
+	BulkLiquid BulkLiquidDetail `json:"bulkLiquid"` //sets BulkLiquidInd when over 119 gallons, noted in the remarks
+
+	HeaviestPiece Weight `json:"heaviestPiece"`           //weight of the heaviest single piece, optional, see Warnings
+	OversizeInd   bool   `json:"oversizeInd,omitempty"`   //a piece is longer, wider, or taller than a standard pallet, noted in the remarks
+	DoNotStackInd bool   `json:"doNotStackInd,omitempty"` //nothing can be stacked on this freight, noted in the remarks
 }
 
-//Weight holds a weight
-type Weight struct {
-	Weight uint `json:"weight"` //int per XPO
+//bulkLiquidGallons is the most a liquid shipment can be before it is bulk liquid
+const bulkLiquidGallons = 119
+
+//BulkLiquidDetail is what dispatch needs to know to send the right equipment for a liquid shipment
+type BulkLiquidDetail struct {
+	Gallons       uint   //US gallons
+	ContainerType string //ex: TOTE, DRUM
+	PumpRequired  bool   //the driver has to pump the liquid, there is no way to load it at the dock
 }
 
-//SuccessfulPickupResponse is the data returned when a pickup is scheduled
-type SuccessfulPickupResponse struct {
-	Code                 string             `json:"code"`
-	TransactionTimestamp uint64             `json:"transactionTimestamp"` //unix timestamp
-	Data                 ConfirmationNumber `json:"data"`
+//IsZero checks if no bulk liquid detail was given
+func (b BulkLiquidDetail) IsZero() bool {
+	return b == BulkLiquidDetail{}
 }
 
-//ConfirmationNumber holds the actual pickup request number
-type ConfirmationNumber struct {
-	PickupID        string `json:"pickupId"`
-	ConfirmationNbr string `json:"confirmationNbr"` //pickup confirmation number
+//String formats the detail for the item remarks, ex: BULK LIQUID 275 GAL TOTE PUMP REQUIRED
+func (b BulkLiquidDetail) String() string {
+	if b.IsZero() {
+		return ""
+	}
+
+	parts := []string{"BULK LIQUID"}
+	if b.Gallons > 0 {
+		parts = append(parts, strconv.FormatUint(uint64(b.Gallons), 10)+" GAL")
+	}
+	if c := strings.TrimSpace(b.ContainerType); c != "" {
+		parts = append(parts, strings.ToUpper(c))
+	}
+	if b.PumpRequired {
+		parts = append(parts, "PUMP REQUIRED")
+	}
+	return strings.Join(parts, " ")
 }
 
-//ErrorPickupResponse is the data returned when a pickup cannot be scheduled
-//XPO API takes in JSON but returns XML upon error
-//each field starts with "am:" but that can be excluded from struct tags
-type ErrorPickupResponse struct {
-	XMLName     xml.Name `xml:"fault"`
-	Code        string   `xml:"code"`
-	Type        string   `xml:"type"`
-	Message     string   `xml:"message"`
-	Description string   `xml:"description"`
+//freezingF is the freezing point of water in degrees Fahrenheit
+const freezingF = 32
+
+//TemperatureRange is the range of temperatures freight can be kept at, in degrees Fahrenheit
+type TemperatureRange struct {
+	Low  int
+	High int
 }
 
-//TokenResponse is the data returned when we retrieve the bearer token
-type TokenResponse struct {
-	BearerToken  string `json:"access_token"`  //not the same as our account access token even though xpo sometimes calls them the same thing
-	RefreshToken string `json:"refresh_token"` //some other token, used to get a new pair of bearer & access tokens
-	Scope        string `json:"scope"`         //default
-	TokenType    string `json:"token_type"`    //Bearer
-	ExpiresIn    uint   `json:"expires_in"`    //43200
+//IsZero checks if no range was given
+func (t TemperatureRange) IsZero() bool {
+	return t.Low == 0 && t.High == 0
 }
 
-//SetProductionMode chooses the production url for use
-func SetProductionMode(yes bool) {
-	if yes {
-		xpoURL = xpoProductionURL
-	}
-	return
+//String formats the range for the item remarks, ex: 40-80F
+func (t TemperatureRange) String() string {
+	return strconv.Itoa(t.Low) + "-" + strconv.Itoa(t.High) + "F"
 }
 
-//SetTimeout updates the timeout value to something the user sets
-//use this to increase the timeout if connecting to XPO is really slow
-func SetTimeout(seconds time.Duration) {
-	timeout = time.Duration(seconds * time.Second)
-	return
+//FoodHandling is how food freight has to be handled
+//XPO only has a food indicator so these are sent in the item remarks.
+type FoodHandling struct {
+	FoodGradeTrailer bool     //trailer must be clean and food grade
+	Allergens        []string //allergens in the freight, ex: PEANUTS
+	KeepAwayFrom     string   //what the freight must be kept apart from, ex: CHEMICALS
 }
 
-//SetCredentials saves our XPO username, password, access token for use later.
-func SetCredentials(u, p, t string) {
-	username = u
-	password = p
-	accessToken = t
-	return
+//IsZero checks if no food handling was given
+func (f FoodHandling) IsZero() bool {
+	return !f.FoodGradeTrailer && len(f.Allergens) == 0 && f.KeepAwayFrom == ""
 }
 
-//RequestPickup performs the API call to schedule a pickup
-//requests to XPO require two steps: getting a token, and making the pickup request.  Why? b/c dumb.
-func (pri *PickupRqstInfo) RequestPickup() (response SuccessfulPickupResponse, err error) {
-	//calculate total weight, pallet count, number of pieces for all items
-	var totalSkids uint
-	var totalPieces uint
-	var totalWeight uint
-	for _, v := range pri.PkupItem {
-		totalSkids += v.PalletCnt
-		totalPieces += v.LoosePiecesCnt
-		totalWeight += v.TotWeight.Weight
+//notes returns the food handling formatted for the item remarks
+func (f FoodHandling) notes() (notes []string) {
+	if f.FoodGradeTrailer {
+		notes = append(notes, "FOOD GRADE TRAILER")
 	}
-	pri.TotPalletCnt = totalSkids
-	pri.TotLoosePieceCnt = totalPieces
-	pri.TotWeight.Weight = totalWeight
-
-	//add the pickup request info to the pickup container object
-	pr := PickupRequest{
-		PickupRqstInfo: *pri,
+	if len(f.Allergens) > 0 {
+		notes = append(notes, "ALLERGENS: "+strings.ToUpper(strings.Join(f.Allergens, ", ")))
 	}
-
-	//convert struct to json
-	jsonBytes, err := json.Marshal(pr)
-	if err != nil {
-		err = errors.Wrap(err, "xpo.RequestPickup - could not marshal json")
-		return
+	if k := strings.TrimSpace(f.KeepAwayFrom); k != "" {
+		notes = append(notes, "KEEP AWAY FROM "+strings.ToUpper(k))
 	}
 
-	//get the token
-	if username == "" || password == "" || accessToken == "" {
-		err = errors.New("xpo.RequestPickup - no access token was provided via SetCredentials()")
-	}
-	bearerToken, err := getRequestToken()
-	if err != nil {
-		err = errors.Wrap(err, "xpo.RequestPickup - could not get token")
-		return
-	}
+	return
+}
 
-	// log.Println("XPO Bearer Token:", bearerToken)
-	// log.Println("XPO Test Mode:", xpoURL)
+//Weight holds a weight in pounds
+//Use Kilograms to set a weight from metric data.
+type Weight struct {
+	Weight uint `json:"weight"` //int per XPO
+}
 
-	//make the call to XPO
-	httpClient := http.Client{
-		Timeout: timeout,
-	}
-	req, err := http.NewRequest("POST", xpoURL, bytes.NewReader(jsonBytes))
-	req.Header.Set("Authorization", "Bearer "+bearerToken)
-	req.Header.Set("Content-Type", "application/json")
-	res, err := httpClient.Do(req)
-	if err != nil {
-		err = errors.Wrap(err, "xpo.RequestPickup - could not make post request")
-		return
-	}
+//SuccessfulPickupResponse is the data returned when a pickup is scheduled
+type SuccessfulPickupResponse struct {
+	Code                 string             `json:"code"`
+	TransactionTimestamp Time               `json:"transactionTimestamp"` //unix timestamp
+	Data                 ConfirmationNumber `json:"data"`
+	Warnings             []Warning          `json:"warnings"` //see AllWarnings
 
-	//read the response
-	defer res.Body.Close()
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		err = errors.Wrap(err, "xpo.RequestPickup - could not read response")
-		return
-	}
+	//copied from the request, not returned by XPO
+	Metadata map[string]string `json:"-"`
 
-	err = json.Unmarshal(body, &response)
-	if err != nil {
-		//data might not be json, might be xml error
-		//try unmarshaling to error xml
-		var errorData ErrorPickupResponse
-		err = xml.Unmarshal(body, &errorData)
-		if err != nil {
-			err = errors.Wrap(err, "xpo.RequestPickup - could not unmarshal response")
-			return
-		}
-
-		//return error so we know we need to fix something
-		log.Printf("%+v", errorData)
-		err = errors.New(errorData.Description)
-		return
-	}
+	//from PickupRqstInfo.Warnings, things about the pickup a person should review like an overweight load
+	ReviewWarnings []string `json:"-"`
+}
 
-	//check if data was returned meaning request was successful
-	//if not, reread the response data and log it
-	if response.Data.ConfirmationNbr == "" {
-		log.Println("xpo.RequestPickup - pickup request failed")
-		log.Println(string(body))
+//check makes sure a confirmation number was returned, meaning the request was successful
+func (s *SuccessfulPickupResponse) check() error {
+	if s.Data.ConfirmationNbr == "" {
+		return errors.New("xpo.RequestPickup - pickup request failed")
+	}
 
-		var errorData ErrorPickupResponse
-		xml.Unmarshal(body, &errorData)
+	return nil
+}
 
-		//return our error so we know where this error came from, and xpo error message so we know what to fix
-		err = errors.New("xpo.RequestPickup - pickup request failed")
-		log.Println(errorData)
-		return
-	}
+//confirmationNbr returns the confirmation number for audit records
+func (s *SuccessfulPickupResponse) confirmationNbr() ConfirmationNbr {
+	return s.Data.ConfirmationNbr
+}
 
-	//pickup request successful
-	//response data will have confirmation number
-	//an email should also have been sent to the requester email
+//AllWarnings returns every warning XPO sent with the response
+//XPO has put warnings both next to and inside of the data so both are checked.
+func (s SuccessfulPickupResponse) AllWarnings() (w []Warning) {
+	w = append(w, s.Warnings...)
+	w = append(w, s.Data.Warnings...)
 	return
 }
 
-//getRequestToken gets a "bearer" token we can use to make a request to the pickup api
-//We request this temporary token using our permanent access token.
-func getRequestToken() (bearerToken string, err error) {
-	httpClient := http.Client{
-		Timeout: timeout,
-	}
+//ConfirmationNumber holds the actual pickup request number
+type ConfirmationNumber struct {
+	PickupID        string          `json:"pickupId"`
+	ConfirmationNbr ConfirmationNbr `json:"confirmationNbr"` //pickup confirmation number
+	Warnings        []Warning       `json:"warnings"`
+}
 
-	//values that must be passed during this request
-	v := url.Values{}
-	v.Add("grant_type", "password")
-	v.Add("username", username)
-	v.Add("password", password)
-
-	//build the request
-	//headers set per xpo
-	req, err := http.NewRequest("POST", xpoTokenURL, bytes.NewBufferString(v.Encode()))
-	req.Header.Set("Authorization", "Basic "+accessToken)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
-	//make the request
-	res, err := httpClient.Do(req)
-	if err != nil {
-		return
-	}
+//ErrorPickupResponse is the data returned when a pickup cannot be scheduled
+//XPO API takes in JSON but returns XML upon error
+type ErrorPickupResponse = transport.Fault
 
-	//parse the response
-	defer res.Body.Close()
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		return
-	}
+//ResponseError is returned when XPO sends back something that can't be decoded, like an html error page
+type ResponseError = transport.ResponseError
 
-	var responseData TokenResponse
-	err = json.Unmarshal(body, &responseData)
-	if err != nil {
-		return
-	}
+//HTTPOptions tunes the connections made to XPO
+type HTTPOptions = transport.HTTPOptions
 
-	//make sure we got a bearer token back
-	bearerToken = responseData.BearerToken
-	if bearerToken == "" {
-		log.Println(string(body))
-		err = errors.New("could not get bearer token from response body")
-		return
-	}
+//Timeouts limits how long each part of a request can take, see Config.EndpointTimeouts
+type Timeouts = transport.Timeouts
 
-	//return the token
-	return
-}
+//NetworkError is returned when a request failed before XPO could respond, see transport.NetworkErrorKind
+type NetworkError = transport.NetworkError
+
+//RetryTransport is an http.RoundTripper that retries requests XPO didn't process
+//Use this with your own http.Client for calls made outside of this package.
+type RetryTransport = transport.RetryTransport
+
+//Backoff decides how long to wait before a retry, see transport.ExponentialBackoff and transport.ConstantBackoff
+type Backoff = transport.Backoff
+
+//RetryBudget limits retries for one endpoint
+type RetryBudget = transport.RetryBudget
+
+//FailoverTransport is an http.RoundTripper that sends requests to a fallback base url when XPO is failing
+type FailoverTransport = transport.FailoverTransport
+
+//TokenResponse is the data returned when we retrieve the bearer token
+type TokenResponse = transport.TokenResponse
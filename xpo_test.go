@@ -0,0 +1,223 @@
+package xpo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+//testPickup returns a valid pickup with a hazmat item, extra contacts, and dock notes so the remarks are built
+//from every source
+func testPickup() PickupRqstInfo {
+	day := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	contact := Contact{
+		CompanyName: "Acme Chemical",
+		Email:       Email{EmailAddr: "shipping@acme.example"},
+		FullName:    "Pat Smith",
+		Phone:       Phone{PhoneNbr: "312-5551212"},
+	}
+
+	return PickupRqstInfo{
+		PkupDate:  NewDate(day),
+		ReadyTime: NewTime(day.Add(14 * time.Hour)),
+		CloseTime: NewTime(day.Add(17 * time.Hour)),
+		PkupItem: []PkupItem{
+			{
+				TotWeight: Weight{Weight: 1200},
+				DestZip6:  "10001",
+				PalletCnt: 2,
+				Hazmat: []HazmatDetail{
+					{UNNumber: "UN1263", ProperShippingName: "Paint", HazardClass: "3", PackingGroup: "II"},
+				},
+			},
+			{
+				TotWeight:      Weight{Weight: 300},
+				DestZip6:       "M5V 2T6",
+				LoosePiecesCnt: 4,
+				DoNotStackInd:  true,
+			},
+		},
+		Shipper: Shipper{
+			AddressLine1:       "100 Industrial Dr",
+			CityName:           "Chicago",
+			StateCd:            "IL",
+			CountryCd:          CountryUS,
+			Name:               "Acme Chemical",
+			PostalCd:           "60601",
+			Phone:              Phone{PhoneNbr: "312-5551212"},
+			DockHours:          DockHours{Open: "08:00", Close: "17:00"},
+			DockDoor:           "4",
+			DriverInstructions: "check in at the guard shack",
+		},
+		Requestor: Requestor{Contact: contact, RoleCd: RoleShipper},
+		Contact:   contact,
+		Remarks:   "call before arrival",
+		Contacts: []RoleContact{
+			{Role: ContactRoleDock, Contact: Contact{FullName: "Sam Lee", Phone: Phone{PhoneNbr: "312-5553434"}}},
+		},
+		HazmatEmergency: HazmatEmergencyContact{
+			Phone:       Phone{PhoneNbr: "800-4249300"},
+			Provider:    "CHEMTREC",
+			ContractNbr: "CCN12345",
+		},
+	}
+}
+
+//fakeXPO is an http.RoundTripper that answers token, pickup, and tracking requests in memory
+type fakeXPO struct {
+	mu          sync.Mutex
+	down        bool   //fail every request as if XPO couldn't be connected to
+	pickupErr   error  //returned for pickup requests, after counting them, as if the connection failed once sent
+	pickupFault string //answer pickup requests with an xml fault with this code
+	pickups     int    //pickup requests received
+
+	shipments map[string]Shipment //answers to tracking requests, keyed by the PRO as tracked
+}
+
+//RoundTrip answers a request the way XPO would
+func (f *fakeXPO) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		ioutil.ReadAll(req.Body)
+		req.Body.Close()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.down {
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	}
+
+	var body string
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/token"):
+		body = `{"access_token":"bearer","expires_in":43200}`
+	case strings.Contains(req.URL.Path, "/pickuprequest/") && f.pickupErr != nil:
+		f.pickups++
+		return nil, f.pickupErr
+	case strings.Contains(req.URL.Path, "/pickuprequest/") && f.pickupFault != "":
+		f.pickups++
+		body = `<am:fault xmlns:am="http://wso2.org/apimanager"><am:code>` + f.pickupFault + `</am:code><am:description>rejected</am:description></am:fault>`
+		return &http.Response{StatusCode: http.StatusBadRequest, Body: ioutil.NopCloser(strings.NewReader(body)), Request: req}, nil
+	case strings.Contains(req.URL.Path, "/pickuprequest/"):
+		f.pickups++
+		body = fmt.Sprintf(`{"code":"200","transactionTimestamp":1791986400000,"data":{"pickupId":"p%d","confirmationNbr":"CHI%06d"}}`, f.pickups, f.pickups)
+	case strings.Contains(req.URL.Path, "/tracking/"):
+		var response TrackingResponse
+		response.Code = "200"
+		for _, pro := range strings.Split(req.URL.Query().Get("referenceNumbers"), ",") {
+			if sh, ok := f.shipments[pro]; ok {
+				response.Data.Shipments = append(response.Data.Shipments, sh)
+			}
+		}
+		b, _ := json.Marshal(response)
+		body = string(b)
+	default:
+		return &http.Response{StatusCode: http.StatusNotFound, Body: ioutil.NopCloser(strings.NewReader("")), Request: req}, nil
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+//setDown makes XPO unreachable, or reachable again
+func (f *fakeXPO) setDown(down bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.down = down
+}
+
+//setShipment sets the tracking answer for a PRO
+func (f *fakeXPO) setShipment(pro string, sh Shipment) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.shipments == nil {
+		f.shipments = map[string]Shipment{}
+	}
+	f.shipments[pro] = sh
+}
+
+//pickupCount returns the number of pickup requests received
+func (f *fakeXPO) pickupCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.pickups
+}
+
+//fakeClient returns a client that sends its requests to f
+func fakeClient(f *fakeXPO, store PickupStore) *Client {
+	c, err := NewClient(Config{
+		Username:    "user",
+		Password:    "secret",
+		AccessToken: "access",
+		HTTPClient:  &http.Client{Transport: f},
+		PickupStore: store,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+//memoryPickupStore is a PickupStore that keeps records in memory, in the order they were saved
+type memoryPickupStore struct {
+	mu      sync.Mutex
+	records []PickupRecord
+}
+
+//SavePickup adds a record
+func (m *memoryPickupStore) SavePickup(ctx context.Context, r PickupRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.records = append(m.records, r)
+	return nil
+}
+
+//UpdatePickup sets a record's status
+func (m *memoryPickupStore) UpdatePickup(ctx context.Context, id string, change PickupStatusChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, r := range m.records {
+		if r.ID == id {
+			m.records[i].Status = change.Status
+			m.records[i].ConfirmationNbr = change.ConfirmationNbr
+			m.records[i].PickupID = change.PickupID
+			m.records[i].Error = change.Error
+			m.records[i].UpdatedAt = change.Time
+			return nil
+		}
+	}
+
+	return errors.New("pickup not found")
+}
+
+//Pickups returns every record, the range is ignored
+func (m *memoryPickupStore) Pickups(ctx context.Context, from, to time.Time) ([]PickupRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]PickupRecord(nil), m.records...), nil
+}
+
+//all returns a copy of the records
+func (m *memoryPickupStore) all() []PickupRecord {
+	records, _ := m.Pickups(context.Background(), time.Time{}, time.Time{})
+	return records
+}